@@ -9,6 +9,8 @@ const BOMB_WORLD_MULTIPLIER = 5;
 const BOMB_MODE_WORLD_SIZE = WORLD_SIZE * BOMB_WORLD_MULTIPLIER;
 const HIDE_SEEK_WORLD_MULTIPLIER = 3;
 const HIDE_SEEK_WORLD_SIZE = WORLD_SIZE * HIDE_SEEK_WORLD_MULTIPLIER;
+const KING_WORLD_MULTIPLIER = 2;
+const KING_WORLD_SIZE = WORLD_SIZE * KING_WORLD_MULTIPLIER;
 const MIN_BOARD_SIZE = 260;
 const GRID_SIZE = 10;
 const GRID_CELL_SIZE = WORLD_SIZE / GRID_SIZE;
@@ -111,13 +113,50 @@ const STATUS_EFFECTS = {
   timeDecrease: {
     icon: "🕒⬇️",
     label: "Уменьшение времени: на поимку рыбки выделяется 5 секунд"
+  },
+  invert: {
+    icon: "🔄",
+    label: "Путаница: управление перевёрнуто"
+  },
+  shield: {
+    icon: "🛡",
+    label: "Щит: одна мина не взорвёт котика"
+  },
+  magnet: {
+    icon: "🧲",
+    label: "Магнит: рыбка сама плывёт к котику"
+  },
+  ghost: {
+    icon: "👻",
+    label: "Призрак: котик проходит сквозь стены"
+  },
+  haunted: {
+    icon: "🥶",
+    label: "Проклятие: призрак замедлил котика"
+  },
+  marked: {
+    icon: "🎯",
+    label: "Метка: призрак показал всем, что у котика бомба"
   }
 };
 
-const STATUS_EFFECT_TYPES = Object.keys(STATUS_EFFECTS);
+// Only these effects exist in the single-player game; the rest come from
+// server power-ups.
+const STATUS_EFFECT_TYPES = ["speedUp", "timeIncrease", "speedDown", "timeDecrease"];
+
+const POWER_UP_ICONS = {
+  fast: "👢",
+  slow: "🧪",
+  invert: "🔄",
+  shield: "🛡",
+  magnet: "🧲",
+  ghost: "👻",
+  timeIncrease: "🕒",
+  timeDecrease: "⌛"
+};
 
 let activeStatusEffect = null;
-let displayedStatusEffect = null;
+let displayedStatusEffects = [];
 let lastResultReason = null;
 
 const keys = new Set();
@@ -135,9 +174,12 @@ function getWorldSizeForMode(mode) {
   if (mode === "bomb-pass") {
     return BOMB_MODE_WORLD_SIZE;
   }
-  if (mode === "hide-and-seek" || mode === "shooters") {
+  if (mode === "hide-and-seek" || mode === "shooters" || mode === "capture-the-fish") {
     return HIDE_SEEK_WORLD_SIZE;
   }
+  if (mode === "king-of-the-hill") {
+    return KING_WORLD_SIZE;
+  }
   return WORLD_SIZE;
 }
 
@@ -151,6 +193,12 @@ function getModeLabel(mode) {
   if (mode === "shooters") {
     return "Стрелялки";
   }
+  if (mode === "capture-the-fish") {
+    return "Захват рыбки";
+  }
+  if (mode === "king-of-the-hill") {
+    return "Царь горы";
+  }
   return "Охота за рыбкой";
 }
 
@@ -910,17 +958,22 @@ function updateStatusEffectIndicator() {
   if (!statusEffectIconEl) {
     return;
   }
-  if (!displayedStatusEffect) {
+  if (displayedStatusEffects.length === 0) {
     statusEffectIconEl.textContent = "—";
     statusEffectIconEl.setAttribute("aria-label", "Нет эффектов");
     return;
   }
 
-  const effect = STATUS_EFFECTS[displayedStatusEffect.type];
-  if (effect) {
-    statusEffectIconEl.textContent = effect.icon;
-    statusEffectIconEl.setAttribute("aria-label", effect.label);
-  }
+  statusEffectIconEl.textContent = displayedStatusEffects
+    .map((effect) => {
+      const icon = STATUS_EFFECTS[effect.type].icon;
+      return effect.stacks > 1 ? `${icon}×${effect.stacks}` : icon;
+    })
+    .join(" ");
+  statusEffectIconEl.setAttribute(
+    "aria-label",
+    displayedStatusEffects.map((effect) => STATUS_EFFECTS[effect.type].label).join("; ")
+  );
 }
 
 function clearStatusEffect() {
@@ -973,34 +1026,23 @@ function applyRandomStatusEffect() {
 }
 
 function setDisplayedStatusEffect(effect) {
-  if (effect && STATUS_EFFECTS[effect.type]) {
-    displayedStatusEffect = {
+  setDisplayedStatusEffects(effect ? [effect] : []);
+}
+
+function setDisplayedStatusEffects(effects) {
+  displayedStatusEffects = (Array.isArray(effects) ? effects : [])
+    .filter((effect) => effect && STATUS_EFFECTS[effect.type])
+    .map((effect) => ({
       type: effect.type,
       remaining: effect.remaining ?? 0,
-      playerId: effect.playerId
-    };
-  } else {
-    displayedStatusEffect = null;
-  }
+      stacks: effect.stacks || 1
+    }));
   updateStatusEffectIndicator();
 }
 
-function syncMultiplayerStatusEffect(effect) {
-  if (isStatusEffectRelevant(effect)) {
-    setDisplayedStatusEffect(effect);
-  } else {
-    setDisplayedStatusEffect(null);
-  }
-}
-
-function isStatusEffectRelevant(effect) {
-  if (!effect || !STATUS_EFFECTS[effect.type]) {
-    return false;
-  }
-  if (effect.playerId && effect.playerId !== playerId) {
-    return false;
-  }
-  return true;
+function getLocalPlayerEffects(state) {
+  const localPlayer = (state?.players || []).find((player) => player.id === playerId);
+  return Array.isArray(localPlayer?.effects) ? localPlayer.effects : [];
 }
 
 function getCatSpeedMultiplier() {
@@ -2766,6 +2808,31 @@ function drawBombLabel(playerState, timer) {
   ctx.restore();
 }
 
+function drawGhostCat(playerState) {
+  if (!playerState || !ctx) {
+    return;
+  }
+  ctx.save();
+  ctx.globalAlpha = 0.35;
+  drawCatSprite(playerState);
+  ctx.restore();
+}
+
+function drawMarkedRing(playerState) {
+  if (!playerState || !ctx) {
+    return;
+  }
+  const pulse = 1 + Math.sin(performance.now() / 150) * 0.1;
+  ctx.save();
+  ctx.strokeStyle = "rgba(186, 104, 255, 0.9)";
+  ctx.lineWidth = 3;
+  ctx.setLineDash([6, 4]);
+  ctx.beginPath();
+  ctx.arc(playerState.x, playerState.y, playerState.size * 0.8 * pulse, 0, Math.PI * 2);
+  ctx.stroke();
+  ctx.restore();
+}
+
 function getCameraOrigin(target, worldSize, viewportSize = getViewportSize()) {
   const half = viewportSize / 2;
   const targetX = target?.x ?? worldSize / 2;
@@ -3092,6 +3159,13 @@ function drawPowerUpSprite(powerUpState) {
     ctx.lineTo(0, half);
     ctx.stroke();
   }
+  const icon = POWER_UP_ICONS[powerUpState.type];
+  if (icon) {
+    ctx.font = `${Math.round(size * 0.55)}px Inter, system-ui, sans-serif`;
+    ctx.textAlign = "center";
+    ctx.textBaseline = "middle";
+    ctx.fillText(icon, 0, 0);
+  }
   ctx.restore();
 }
 
@@ -3172,6 +3246,121 @@ function drawShots(traces = []) {
   ctx.restore();
 }
 
+const TEAM_COLORS = ["#9e9e9e", "#e53935", "#1e88e5", "#43a047", "#fdd835"];
+
+function drawTeamBases(bases = []) {
+  if (!bases || bases.length === 0) {
+    return;
+  }
+  ctx.save();
+  bases.forEach((base) => {
+    const color = TEAM_COLORS[base.team] || TEAM_COLORS[0];
+    ctx.globalAlpha = 0.25;
+    ctx.fillStyle = color;
+    ctx.beginPath();
+    ctx.arc(base.x, base.y, base.radius, 0, Math.PI * 2);
+    ctx.fill();
+    ctx.globalAlpha = 0.9;
+    ctx.strokeStyle = color;
+    ctx.lineWidth = 4;
+    ctx.stroke();
+  });
+  ctx.restore();
+}
+
+function drawHills(hills = [], ownerId) {
+  if (!hills || hills.length === 0) {
+    return;
+  }
+  ctx.save();
+  hills.forEach((hill) => {
+    const half = hill.size / 2;
+    if (hill.contested) {
+      ctx.fillStyle = "rgba(229, 57, 53, 0.3)";
+    } else if (hill.owner) {
+      ctx.fillStyle = hill.owner === ownerId ? "rgba(67, 160, 71, 0.35)" : "rgba(255, 179, 0, 0.35)";
+    } else {
+      ctx.fillStyle = "rgba(255, 255, 255, 0.2)";
+    }
+    ctx.fillRect(hill.x - half, hill.y - half, hill.size, hill.size);
+    ctx.strokeStyle = "rgba(255, 255, 255, 0.8)";
+    ctx.lineWidth = 3;
+    ctx.strokeRect(hill.x - half, hill.y - half, hill.size, hill.size);
+  });
+  ctx.restore();
+}
+
+function drawHazards(hazards = []) {
+  if (!hazards || hazards.length === 0) {
+    return;
+  }
+  ctx.save();
+  hazards.forEach((hazard) => {
+    if (hazard.warning > 0) {
+      const blink = Math.sin(performance.now() / 120) > 0;
+      ctx.fillStyle = blink ? "rgba(255, 152, 0, 0.45)" : "rgba(255, 152, 0, 0.2)";
+      ctx.fillRect(hazard.x, hazard.y, hazard.size, hazard.size);
+      ctx.fillStyle = "#fff";
+      ctx.font = "24px Inter, system-ui, sans-serif";
+      ctx.textAlign = "center";
+      ctx.fillText("⚠", hazard.x + hazard.size / 2, hazard.y + hazard.size / 2 + 8);
+    } else {
+      ctx.fillStyle = "rgba(20, 12, 8, 0.85)";
+      ctx.fillRect(hazard.x, hazard.y, hazard.size, hazard.size);
+      ctx.strokeStyle = "rgba(255, 87, 34, 0.8)";
+      ctx.lineWidth = 2;
+      ctx.strokeRect(hazard.x, hazard.y, hazard.size, hazard.size);
+    }
+  });
+  ctx.restore();
+}
+
+const HIDE_SEEK_PING_LIFETIME = 2;
+
+function drawSoundPings(pings = []) {
+  if (!pings || pings.length === 0) {
+    return;
+  }
+  ctx.save();
+  pings.forEach((ping) => {
+    const progress = 1 - clamp((ping.remaining || 0) / HIDE_SEEK_PING_LIFETIME, 0, 1);
+    ctx.globalAlpha = 1 - progress;
+    ctx.strokeStyle = "#ffca28";
+    ctx.lineWidth = 4;
+    ctx.beginPath();
+    ctx.arc(ping.x, ping.y, 20 + progress * 60, 0, Math.PI * 2);
+    ctx.stroke();
+    ctx.fillStyle = "#ffca28";
+    ctx.font = "24px Inter, system-ui, sans-serif";
+    ctx.textAlign = "center";
+    ctx.fillText("♪", ping.x, ping.y + 8);
+  });
+  ctx.restore();
+}
+
+function drawSafeZone(zone, worldSize) {
+  if (!zone || !(zone.radius > 0)) {
+    return;
+  }
+  ctx.save();
+  ctx.fillStyle = "rgba(120, 40, 160, 0.25)";
+  ctx.beginPath();
+  ctx.rect(0, 0, worldSize, worldSize);
+  ctx.arc(zone.x, zone.y, zone.radius, 0, Math.PI * 2, true);
+  ctx.fill();
+  ctx.strokeStyle = "rgba(255, 255, 255, 0.8)";
+  ctx.lineWidth = 3;
+  ctx.beginPath();
+  ctx.arc(zone.x, zone.y, zone.radius, 0, Math.PI * 2);
+  ctx.stroke();
+  ctx.setLineDash([8, 8]);
+  ctx.strokeStyle = "rgba(255, 255, 255, 0.4)";
+  ctx.beginPath();
+  ctx.arc(zone.x, zone.y, zone.targetRadius, 0, Math.PI * 2);
+  ctx.stroke();
+  ctx.restore();
+}
+
 function drawMines() {
   drawMinesCollection(mines);
 }
@@ -3322,21 +3511,41 @@ function applyMultiplayerStatePatch(previousState, patch) {
   if (patch.message !== undefined) {
     nextState.message = patch.message;
   }
-  if (patch.seekerId !== undefined) {
-    nextState.seekerId = patch.seekerId;
+  if (Array.isArray(patch.seekerIds)) {
+    nextState.seekerIds = [...patch.seekerIds];
   }
-  if (patch.bombHolder !== undefined) {
-    nextState.bombHolder = patch.bombHolder;
-  }
-  if (patch.bombTimer !== undefined) {
-    nextState.bombTimer = patch.bombTimer;
+  if (Array.isArray(patch.bombs)) {
+    nextState.bombs = patch.bombs.map((bomb) => ({ ...bomb }));
   }
   if (patch.winnerId !== undefined) {
     nextState.winnerId = patch.winnerId;
   }
-  if (patch.statusEffect !== undefined) {
-    const effect = patch.statusEffect;
-    nextState.statusEffect = effect && effect.type ? effect : null;
+  if (patch.hostId !== undefined) {
+    nextState.hostId = patch.hostId;
+  }
+  if (patch.winnerTeam !== undefined) {
+    nextState.winnerTeam = patch.winnerTeam;
+  }
+  if (patch.fishCarrier !== undefined) {
+    nextState.fishCarrier = patch.fishCarrier;
+  }
+  if (Array.isArray(patch.hills)) {
+    nextState.hills = patch.hills.map((hill) => ({ ...hill }));
+  }
+  if (Array.isArray(patch.pings)) {
+    nextState.pings = patch.pings.map((ping) => ({ ...ping }));
+  }
+  if (Array.isArray(patch.hazards)) {
+    nextState.hazards = patch.hazards.map((hazard) => ({ ...hazard }));
+  }
+  if (Array.isArray(patch.bases)) {
+    nextState.bases = patch.bases.map((base) => ({ ...base }));
+  }
+  if (patch.settings) {
+    nextState.settings = { ...patch.settings };
+  }
+  if (patch.zone !== undefined) {
+    nextState.zone = patch.zone && patch.zone.radius > 0 ? { ...patch.zone } : null;
   }
   if (patch.fish) {
     nextState.fish = { ...previousState.fish, ...patch.fish };
@@ -3392,6 +3601,7 @@ function normalizeMultiplayerPlayer(player) {
   normalized.disguise = typeof normalized.disguise === "string" ? normalized.disguise : "";
   normalized.health = Math.max(0, Number(normalized.health)) || SHOOTER_MAX_HEALTH;
   normalized.weapon = typeof normalized.weapon === "string" ? normalized.weapon : "";
+  normalized.effects = Array.isArray(normalized.effects) ? normalized.effects : [];
   return normalized;
 }
 
@@ -3409,6 +3619,7 @@ class MultiplayerManager {
     this.ready = false;
     this.inputVector = { x: 0, y: 0 };
     this.shootIntent = false;
+    this.aimVector = { x: 1, y: 0 };
     this.lastInputSentAt = 0;
     this.rendering = false;
     this.renderFrameBound = (timestamp) => this.renderFrame(timestamp);
@@ -3603,6 +3814,14 @@ class MultiplayerManager {
       case "protocol":
         this.handleProtocolMessage(message);
         break;
+      case "achievement":
+        if (message.achievement) {
+          this.handleChatMessage({
+            name: "Достижение",
+            text: `${message.achievement.title}: ${message.achievement.description}`
+          });
+        }
+        break;
       case "error":
         if (multiplayerErrorEl) {
           multiplayerErrorEl.textContent = message.message || "Не удалось подключиться.";
@@ -3661,10 +3880,12 @@ class MultiplayerManager {
     this.smoothingStartTime = now;
     this.state = nextState;
     this.mode = nextState.mode || this.mode;
-    this.worldSize = getWorldSizeForMode(this.mode);
+    this.worldSize = nextState.settings?.worldScale
+      ? WORLD_SIZE * nextState.settings.worldScale
+      : getWorldSizeForMode(this.mode);
     const previousPhase = previousState?.phase;
     this.handleStateAudio(previousState, nextState);
-    syncMultiplayerStatusEffect(nextState.statusEffect);
+    setDisplayedStatusEffects(getLocalPlayerEffects(nextState));
     if (nextState.phase === "playing" || nextState.phase === "countdown") {
       hideMultiplayerOverlay();
       hideMultiplayerChat({ reset: false });
@@ -3721,9 +3942,12 @@ class MultiplayerManager {
       this.lastLocalStepAccumulator = 0;
     }
 
-    const prevEffectType = previousState?.statusEffect?.type;
-    const nextEffectType = nextState?.statusEffect?.type;
-    if (nextEffectType && nextEffectType !== prevEffectType) {
+    const prevEffects = getLocalPlayerEffects(previousState);
+    const gainedEffect = getLocalPlayerEffects(nextState).some((effect) => {
+      const previous = prevEffects.find((prevEffect) => prevEffect.type === effect.type);
+      return !previous || effect.remaining > previous.remaining;
+    });
+    if (gainedEffect) {
       soundManager.playCatch();
     }
   }
@@ -3823,26 +4047,45 @@ class MultiplayerManager {
       drawBombPassBackground(worldSize);
     }
 
+    if (this.mode === "capture-the-fish") {
+      drawTeamBases(this.state?.bases);
+    }
+    if (this.mode === "king-of-the-hill") {
+      drawHills(this.state?.hills, this.playerId);
+    }
+    drawHazards(this.state?.hazards);
     drawWallsCollection(walls);
     drawMinesCollection(mines);
     powerUps.forEach((powerUpState) => drawPowerUpSprite(powerUpState));
     drawPowerUpSprite(powerUp);
     if (this.mode === "shooters") {
       drawShots(shots);
+      drawSafeZone(this.state?.zone, worldSize);
     }
     drawFishSprite(fish);
+    const showGhosts = this.mode === "bomb-pass" && this.state?.phase === "playing";
     players.forEach((player) => {
       if (this.mode === "hide-and-seek" && player.disguise) {
         drawDisguisedPlayer(player);
+      } else if (showGhosts && !player.alive) {
+        drawGhostCat(player);
       } else {
         drawCatSprite(player);
       }
-    });
-    if (this.mode === "bomb-pass" && this.state?.bombHolder) {
-      const holder = players.find((player) => player.id === this.state.bombHolder);
-      if (holder) {
-        drawBombLabel(holder, this.state.bombTimer ?? 0);
+      if (player.effects?.some((effect) => effect.type === "marked")) {
+        drawMarkedRing(player);
       }
+    });
+    if (this.mode === "hide-and-seek") {
+      drawSoundPings(this.state?.pings);
+    }
+    if (this.mode === "bomb-pass") {
+      (this.state?.bombs || []).forEach((bomb) => {
+        const holder = players.find((player) => player.id === bomb.holder);
+        if (holder) {
+          drawBombLabel(holder, bomb.timer ?? 0);
+        }
+      });
     }
 
     if (camera) {
@@ -3850,13 +4093,22 @@ class MultiplayerManager {
     }
 
     if (this.state && isExpandedWorld) {
-      const isSeeker = this.mode === "hide-and-seek" && this.state.seekerId === this.playerId;
+      const isSeeker = this.mode === "hide-and-seek" && this.isSeeker(this.playerId);
       const minimapPlayers = isSeeker ? players.filter((player) => player.id === this.playerId) : players;
       drawMinimap(minimapPlayers, walls, worldSize, viewSize, camera);
     }
     multiplayerRenderHandle = requestAnimationFrame(this.renderFrameBound);
   }
 
+  isSeeker(playerId) {
+    return Array.isArray(this.state?.seekerIds) && this.state.seekerIds.includes(playerId);
+  }
+
+  getNextBomb() {
+    const bombs = this.state?.bombs || [];
+    return bombs.reduce((next, bomb) => (!next || bomb.timer < next.timer ? bomb : next), null);
+  }
+
   isSeekerBlindfolded() {
     if (this.mode !== "hide-and-seek" || !this.state) {
       return false;
@@ -3864,8 +4116,7 @@ class MultiplayerManager {
     if (this.state.phase !== "playing") {
       return false;
     }
-    const seekerId = this.state.seekerId;
-    if (!seekerId || seekerId !== this.playerId) {
+    if (!this.isSeeker(this.playerId)) {
       return false;
     }
     return this.state.hidePhase === "hiding";
@@ -3900,9 +4151,9 @@ class MultiplayerManager {
       return this.lastLocalCameraTarget;
     }
 
-    const bombHolderId = this.state?.bombHolder;
-    if (bombHolderId) {
-      const holder = players.find((player) => player.id === bombHolderId);
+    const nextBomb = this.getNextBomb();
+    if (nextBomb) {
+      const holder = players.find((player) => player.id === nextBomb.holder);
       if (holder) {
         return holder;
       }
@@ -4000,6 +4251,33 @@ class MultiplayerManager {
     this.sendMessage({ type: "ready", ready: this.ready });
   }
 
+  requestDisguise() {
+    if (this.mode === "hide-and-seek") {
+      this.sendMessage({ type: "disguise" });
+    }
+  }
+
+  requestTaunt() {
+    if (this.mode === "hide-and-seek") {
+      this.sendMessage({ type: "taunt" });
+    }
+  }
+
+  requestHaunt() {
+    const localPlayer = this.state?.players?.find((player) => player.id === this.playerId);
+    if (this.mode === "bomb-pass" && localPlayer && !localPlayer.alive) {
+      this.sendMessage({ type: "haunt" });
+    }
+  }
+
+  chooseTeam(team) {
+    const value = Number(team);
+    if (!Number.isInteger(value) || value < 1 || this.state?.settings?.autoBalance) {
+      return;
+    }
+    this.sendMessage({ type: "team", team: value });
+  }
+
   updateAppearance(appearance) {
     if (!appearance) {
       return;
@@ -4053,16 +4331,20 @@ class MultiplayerManager {
   }
 
   sendInput(vector, shoot = false) {
+    const aim = this.aimVector;
     if (this.useBinaryProtocol) {
-      this.sendBinary(encodeInputToBuffer(this.playerId, vector, shoot));
+      this.sendBinary(encodeInputToBuffer(this.playerId, vector, shoot, aim));
       return;
     }
-    this.sendMessage({ type: "input", vector, shoot });
+    this.sendMessage({ type: "input", vector, shoot, aim });
   }
 
   updateInputFromControls() {
     const raw = getRawInputVector();
     const vector = { x: raw.x, y: raw.y };
+    if (Math.abs(vector.x) > 0.01 || Math.abs(vector.y) > 0.01) {
+      this.aimVector = { x: vector.x, y: vector.y };
+    }
     const changed =
       Math.abs(vector.x - this.inputVector.x) > 0.02 ||
       Math.abs(vector.y - this.inputVector.y) > 0.02;
@@ -4156,29 +4438,51 @@ class MultiplayerManager {
         }
         return (b.score || 0) - (a.score || 0);
       });
-      const bombHolderId = this.state.bombHolder;
+      const bombHolderIds = new Set((this.state.bombs || []).map((bomb) => bomb.holder));
       sorted.forEach((player) => {
         const item = document.createElement("li");
         let status = "Ждёт";
         if (phase === "playing") {
-          status = player.alive ? "В игре" : "Выбыл";
+          status = player.alive ? "В игре" : isBombMode ? "Призрак" : "Выбыл";
         } else if (phase === "countdown") {
           status = "Готовится";
         } else if (phase === "ended") {
-          status = this.state.winnerId === player.id ? "Победитель" : "Итог";
+          const teamWon = this.state.winnerTeam > 0 && player.team === this.state.winnerTeam;
+          status = this.state.winnerId === player.id || teamWon ? "Победитель" : "Итог";
         }
-        if (isBombMode && phase === "playing" && bombHolderId === player.id) {
+        if (isBombMode && phase === "playing" && bombHolderIds.has(player.id)) {
           status = `${status} · 💣`;
         }
+        if (this.mode === "hide-and-seek" && phase === "playing" && this.isSeeker(player.id)) {
+          status = `${status} · 👁`;
+        }
+        if (phase === "playing" && this.state.fishCarrier === player.id) {
+          status = `${status} · 🐟`;
+        }
         let rightText = `${player.score} · ${status}`;
         if (isBombMode) {
           rightText = status;
+          if (phase === "playing" && !player.alive && player.id === this.playerId) {
+            const hauntText = player.abilityCooldown > 0 ? `⏳${Math.ceil(player.abilityCooldown)}` : "E";
+            rightText = `${rightText} · 👻 ${hauntText}`;
+          }
         } else if (isShooterMode) {
-          const healthText = `${Math.max(0, player.health ?? SHOOTER_MAX_HEALTH)}❤`;
+          const armorText = player.armor > 0 ? ` +${player.armor}🛡` : "";
+          const healthText = `${Math.max(0, player.health ?? SHOOTER_MAX_HEALTH)}❤${armorText}`;
           const weaponText = player.weapon ? player.weapon : "без оружия";
           rightText = `${healthText} · ${weaponText} · ${status}`;
+        } else if (this.mode === "hide-and-seek" && phase === "playing" && player.id === this.playerId) {
+          if (this.isSeeker(player.id)) {
+            if (player.abilityCooldown > 0) {
+              rightText = `${rightText} · ⏳${Math.ceil(player.abilityCooldown)}`;
+            }
+          } else {
+            const tauntText = player.abilityCooldown > 0 ? `♪${Math.ceil(player.abilityCooldown)}` : "♪ Q";
+            rightText = `${rightText} · 🎭${player.charges ?? 0} E · ${tauntText}`;
+          }
         }
-        item.innerHTML = `<span>${escapeHtml(player.name)}</span><span>${rightText}</span>`;
+        const teamText = player.team > 0 ? ` [${player.team}]` : "";
+        item.innerHTML = `<span>${escapeHtml(player.name)}${teamText}</span><span>${rightText}</span>`;
         multiplayerHudPlayers.appendChild(item);
       });
     }
@@ -4187,7 +4491,9 @@ class MultiplayerManager {
         multiplayerCountdownEl.textContent = `Старт через ${Math.ceil(this.state.countdown || 0)} с`;
       } else if (phase === "playing") {
         if (isBombMode) {
-          multiplayerCountdownEl.textContent = `Бомба: ${(this.state.bombTimer || 0).toFixed(1)} c`;
+          const bombCount = this.state.bombs?.length || 0;
+          const bombLabel = bombCount > 1 ? `Бомбы (${bombCount})` : "Бомба";
+          multiplayerCountdownEl.textContent = `${bombLabel}: ${(this.getNextBomb()?.timer || 0).toFixed(1)} c`;
         } else if (isShooterMode && this.state.shootPhase === "loot") {
           multiplayerCountdownEl.textContent = `Подготовка: ${(this.state.countdown || 0).toFixed(1)} c`;
         } else {
@@ -4212,7 +4518,7 @@ class MultiplayerManager {
     if (timerEl) {
       let timerValue = phase === "countdown" ? this.state.countdown : this.state.remaining;
       if (this.mode === "bomb-pass" && phase === "playing") {
-        timerValue = this.state.bombTimer;
+        timerValue = this.getNextBomb()?.timer ?? 0;
       } else if (this.mode === "shooters" && phase === "playing" && this.state.shootPhase === "loot") {
         timerValue = this.state.countdown ?? timerValue;
       }
@@ -4253,6 +4559,13 @@ window.addEventListener("keydown", (event) => {
   if (gameMode === "multiplayer" && multiplayerManager && key === " ") {
     multiplayerManager.requestShoot();
   }
+  if (gameMode === "multiplayer" && multiplayerManager && !event.repeat && key === "e") {
+    multiplayerManager.requestDisguise();
+    multiplayerManager.requestHaunt();
+  }
+  if (gameMode === "multiplayer" && multiplayerManager && !event.repeat && key === "q") {
+    multiplayerManager.requestTaunt();
+  }
   if (gameMode === "multiplayer" && multiplayerManager) {
     multiplayerManager.updateInputFromControls();
   }
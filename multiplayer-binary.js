@@ -20,7 +20,14 @@ const POWER_UP_TYPE_CODES = {
   blaster: 13,
   laser: 14,
   pistol: 15,
-  plasma: 16
+  plasma: 16,
+  health: 17,
+  armor: 18,
+  shield: 19,
+  magnet: 20,
+  ghost: 21,
+  timeIncrease: 22,
+  timeDecrease: 23
 };
 const MESSAGE_TYPES = { full: 0, patch: 1 };
 
@@ -261,6 +268,64 @@ function decodePowerUps(reader) {
   return powerUps;
 }
 
+function encodeSettings(settings = {}, writer) {
+  writer.writeFloat32(settings.roundDuration || 0);
+  writer.writeFloat32(settings.shooterRoundDuration || 0);
+  writer.writeFloat32(settings.shooterPrepDuration || 0);
+  writer.writeFloat32(settings.bombTimerDuration || 0);
+  writer.writeFloat32(settings.bombTimerBonus || 0);
+  writer.writeUint16(settings.shooterDamage >>> 0);
+  writer.writeUint16(settings.shooterMaxHealth >>> 0);
+  writer.writeFloat32(settings.hideDuration || 0);
+  writer.writeFloat32(settings.seekDuration || 0);
+  writer.writeUint8(settings.maxMines >>> 0);
+  writer.writeFloat32(settings.worldScale || 0);
+  writer.writeUint8(settings.fishPoints >>> 0);
+  writer.writeUint8(settings.goldenFishPoints >>> 0);
+  writer.writeFloat32(settings.goldenFishChance || 0);
+  writer.writeFloat32(settings.timeFishChance || 0);
+  writer.writeFloat32(settings.timeFishSeconds || 0);
+  writer.writeFloat32(settings.goldenChainDuration || 0);
+  writer.writeUint8(settings.teams >>> 0);
+  writer.writeBool(Boolean(settings.friendlyFire));
+  writer.writeBool(Boolean(settings.autoBalance));
+  writer.writeBool(Boolean(settings.infection));
+  writer.writeFloat32(settings.bombBlastRadius || 0);
+  writer.writeBool(Boolean(settings.hotPotato));
+  writer.writeFloat32(settings.arenaEventInterval || 0);
+  writer.writeString(settings.map || "");
+}
+
+function decodeSettings(reader) {
+  return {
+    roundDuration: reader.readFloat32(),
+    shooterRoundDuration: reader.readFloat32(),
+    shooterPrepDuration: reader.readFloat32(),
+    bombTimerDuration: reader.readFloat32(),
+    bombTimerBonus: reader.readFloat32(),
+    shooterDamage: reader.readUint16(),
+    shooterMaxHealth: reader.readUint16(),
+    hideDuration: reader.readFloat32(),
+    seekDuration: reader.readFloat32(),
+    maxMines: reader.readUint8(),
+    worldScale: reader.readFloat32(),
+    fishPoints: reader.readUint8(),
+    goldenFishPoints: reader.readUint8(),
+    goldenFishChance: reader.readFloat32(),
+    timeFishChance: reader.readFloat32(),
+    timeFishSeconds: reader.readFloat32(),
+    goldenChainDuration: reader.readFloat32(),
+    teams: reader.readUint8(),
+    friendlyFire: reader.readBool(),
+    autoBalance: reader.readBool(),
+    infection: reader.readBool(),
+    bombBlastRadius: reader.readFloat32(),
+    hotPotato: reader.readBool(),
+    arenaEventInterval: reader.readFloat32(),
+    map: reader.readString()
+  };
+}
+
 function encodePlayers(players = [], writer) {
   writer.writeUint8(Math.min(players.length, 32));
   players.slice(0, 32).forEach((player) => {
@@ -281,9 +346,204 @@ function encodePlayers(players = [], writer) {
     const appearance = player.appearance ? JSON.stringify(player.appearance).slice(0, 300) : "";
     writer.writeString(appearance || "");
     writer.writeString(player.disguise || "");
+    writer.writeUint16(player.ammo >>> 0);
+    writer.writeFloat32(player.cooldown || 0);
+    writer.writeUint16(player.charges >>> 0);
+    writer.writeFloat32(player.abilityCooldown || 0);
+    writer.writeUint16(player.armor >>> 0);
+    writer.writeUint8(player.team >>> 0);
+    encodeEffects(player.effects, writer);
+  });
+}
+
+function encodeEffects(effects = [], writer) {
+  const list = Array.isArray(effects) ? effects.slice(0, 8) : [];
+  writer.writeUint8(list.length);
+  list.forEach((effect) => {
+    writer.writeString(effect.type || "");
+    writer.writeFloat32(effect.remaining || 0);
+    writer.writeUint8(effect.stacks >>> 0);
+  });
+}
+
+function decodeEffects(reader) {
+  const count = reader.readUint8();
+  const effects = [];
+  for (let i = 0; i < count; i += 1) {
+    effects.push({
+      type: reader.readString(),
+      remaining: reader.readFloat32(),
+      stacks: reader.readUint8()
+    });
+  }
+  return effects;
+}
+
+function encodeBases(bases = [], writer) {
+  const list = Array.isArray(bases) ? bases.slice(0, 8) : [];
+  writer.writeUint8(list.length);
+  list.forEach((base) => {
+    writer.writeUint8(base.team >>> 0);
+    writer.writeFloat32(base.x || 0);
+    writer.writeFloat32(base.y || 0);
+    writer.writeFloat32(base.radius || 0);
+  });
+}
+
+function decodeBases(reader) {
+  const count = reader.readUint8();
+  const bases = [];
+  for (let i = 0; i < count; i += 1) {
+    bases.push({
+      team: reader.readUint8(),
+      x: reader.readFloat32(),
+      y: reader.readFloat32(),
+      radius: reader.readFloat32()
+    });
+  }
+  return bases;
+}
+
+function encodeHills(hills = [], writer) {
+  const list = Array.isArray(hills) ? hills.slice(0, 16) : [];
+  writer.writeUint8(list.length);
+  list.forEach((hill) => {
+    writer.writeUint8(hill.row >>> 0);
+    writer.writeUint8(hill.col >>> 0);
+    writer.writeFloat32(hill.x || 0);
+    writer.writeFloat32(hill.y || 0);
+    writer.writeFloat32(hill.size || 0);
+    writer.writeString(hill.owner || "");
+    writer.writeBool(Boolean(hill.contested));
+  });
+}
+
+function decodeHills(reader) {
+  const count = reader.readUint8();
+  const hills = [];
+  for (let i = 0; i < count; i += 1) {
+    hills.push({
+      row: reader.readUint8(),
+      col: reader.readUint8(),
+      x: reader.readFloat32(),
+      y: reader.readFloat32(),
+      size: reader.readFloat32(),
+      owner: reader.readString(),
+      contested: reader.readBool()
+    });
+  }
+  return hills;
+}
+
+function encodeStrings(values = [], writer) {
+  const list = Array.isArray(values) ? values.slice(0, 0xffff) : [];
+  writer.writeUint16(list.length);
+  list.forEach((value) => writer.writeString(value || ""));
+}
+
+function decodeStrings(reader) {
+  const count = reader.readUint16();
+  const values = [];
+  for (let i = 0; i < count; i += 1) {
+    values.push(reader.readString());
+  }
+  return values;
+}
+
+function encodeHazards(hazards = [], writer) {
+  const list = Array.isArray(hazards) ? hazards.slice(0, 32) : [];
+  writer.writeUint8(list.length);
+  list.forEach((hazard) => {
+    writer.writeUint8(hazard.row >>> 0);
+    writer.writeUint8(hazard.col >>> 0);
+    writer.writeFloat32(hazard.x || 0);
+    writer.writeFloat32(hazard.y || 0);
+    writer.writeFloat32(hazard.size || 0);
+    writer.writeFloat32(hazard.warning || 0);
+    writer.writeFloat32(hazard.remaining || 0);
+  });
+}
+
+function decodeHazards(reader) {
+  const count = reader.readUint8();
+  const hazards = [];
+  for (let i = 0; i < count; i += 1) {
+    hazards.push({
+      row: reader.readUint8(),
+      col: reader.readUint8(),
+      x: reader.readFloat32(),
+      y: reader.readFloat32(),
+      size: reader.readFloat32(),
+      warning: reader.readFloat32(),
+      remaining: reader.readFloat32()
+    });
+  }
+  return hazards;
+}
+
+function encodeBombs(bombs = [], writer) {
+  const list = Array.isArray(bombs) ? bombs.slice(0, 16) : [];
+  writer.writeUint8(list.length);
+  list.forEach((bomb) => {
+    writer.writeString(bomb.holder || "");
+    writer.writeFloat32(bomb.timer || 0);
+    writer.writeUint8(Math.min(bomb.passes >>> 0, 255));
+  });
+}
+
+function decodeBombs(reader) {
+  const count = reader.readUint8();
+  const bombs = [];
+  for (let i = 0; i < count; i += 1) {
+    bombs.push({
+      holder: reader.readString(),
+      timer: reader.readFloat32(),
+      passes: reader.readUint8()
+    });
+  }
+  return bombs;
+}
+
+function encodePings(pings = [], writer) {
+  const list = Array.isArray(pings) ? pings.slice(0, 16) : [];
+  writer.writeUint8(list.length);
+  list.forEach((ping) => {
+    writer.writeFloat32(ping.x || 0);
+    writer.writeFloat32(ping.y || 0);
+    writer.writeFloat32(ping.remaining || 0);
   });
 }
 
+function decodePings(reader) {
+  const count = reader.readUint8();
+  const pings = [];
+  for (let i = 0; i < count; i += 1) {
+    pings.push({
+      x: reader.readFloat32(),
+      y: reader.readFloat32(),
+      remaining: reader.readFloat32()
+    });
+  }
+  return pings;
+}
+
+function encodeZone(zone, writer) {
+  writer.writeFloat32(zone?.x || 0);
+  writer.writeFloat32(zone?.y || 0);
+  writer.writeFloat32(zone?.radius || 0);
+  writer.writeFloat32(zone?.targetRadius || 0);
+}
+
+function decodeZone(reader) {
+  const zone = {
+    x: reader.readFloat32(),
+    y: reader.readFloat32(),
+    radius: reader.readFloat32(),
+    targetRadius: reader.readFloat32()
+  };
+  return zone.radius > 0 ? zone : null;
+}
+
 function decodePlayers(reader) {
   const count = reader.readUint8();
   const players = [];
@@ -314,6 +574,13 @@ function decodePlayers(reader) {
       }
     }
     player.disguise = reader.readString();
+    player.ammo = reader.readUint16();
+    player.cooldown = reader.readFloat32();
+    player.charges = reader.readUint16();
+    player.abilityCooldown = reader.readFloat32();
+    player.armor = reader.readUint16();
+    player.team = reader.readUint8();
+    player.effects = decodeEffects(reader);
     players.push(player);
   }
   return players;
@@ -333,14 +600,8 @@ export function encodeStateToBase64(state) {
   writer.writeBool(Boolean(state.goldenChainActive));
   writer.writeString(state.winnerId || "");
   writer.writeString(state.message || "");
-  writer.writeString(state.seekerId || "");
-  writer.writeString(state.bombHolder || "");
-  writer.writeFloat32(state.bombTimer || 0);
-
-  const statusType = state.statusEffect?.type || "";
-  writer.writeString(statusType);
-  writer.writeFloat32(state.statusEffect?.remaining || 0);
-  writer.writeString(state.statusEffect?.playerId || "");
+  encodeStrings(state.seekerIds, writer);
+  encodeBombs(state.bombs, writer);
 
   const fishTypeCode = FISH_TYPE_CODES[state.fish?.type] ?? 0;
   writer.writeUint8(fishTypeCode);
@@ -364,6 +625,15 @@ export function encodeStateToBase64(state) {
   encodeMines(state.mines, writer);
 
   encodePlayers(state.players || [], writer);
+  writer.writeString(state.hostId || "");
+  encodeSettings(state.settings, writer);
+  encodeZone(state.zone, writer);
+  writer.writeUint8(state.winnerTeam >>> 0);
+  encodeBases(state.bases, writer);
+  writer.writeString(state.fishCarrier || "");
+  encodeHills(state.hills, writer);
+  encodePings(state.pings, writer);
+  encodeHazards(state.hazards, writer);
   return toBase64(writer.toUint8Array());
 }
 
@@ -388,6 +658,7 @@ function decodePlayerPatch(reader) {
   const id = reader.readString();
   const flags1 = reader.readUint8();
   const flags2 = reader.readUint8();
+  const flags3 = reader.readUint8();
   const patch = { id };
   if (flags1 & (1 << 0)) patch.name = reader.readString();
   if (flags1 & (1 << 1)) patch.ready = reader.readBool();
@@ -411,6 +682,13 @@ function decodePlayerPatch(reader) {
   if (flags2 & (1 << 4)) patch.disguise = reader.readString();
   if (flags2 & (1 << 5)) patch.health = reader.readUint16();
   if (flags2 & (1 << 6)) patch.weapon = reader.readString();
+  if (flags3 & (1 << 0)) patch.ammo = reader.readUint16();
+  if (flags3 & (1 << 1)) patch.cooldown = reader.readFloat32();
+  if (flags3 & (1 << 2)) patch.armor = reader.readUint16();
+  if (flags3 & (1 << 3)) patch.team = reader.readUint8();
+  if (flags3 & (1 << 4)) patch.effects = decodeEffects(reader);
+  if (flags3 & (1 << 5)) patch.charges = reader.readUint16();
+  if (flags3 & (1 << 6)) patch.abilityCooldown = reader.readFloat32();
   return patch;
 }
 
@@ -427,12 +705,8 @@ function decodeFullState(reader) {
   const goldenChainActive = reader.readBool();
   const winnerId = reader.readString();
   const message = reader.readString();
-  const seekerId = reader.readString();
-  const bombHolder = reader.readString();
-  const bombTimer = reader.readFloat32();
-  const statusType = reader.readString();
-  const statusRemaining = reader.readFloat32();
-  const statusPlayerId = reader.readString();
+  const seekerIds = decodeStrings(reader);
+  const bombs = decodeBombs(reader);
   const fishType = reader.readUint8();
   const fish = {
     type: Object.keys(FISH_TYPE_CODES).find((key) => FISH_TYPE_CODES[key] === fishType) || "normal",
@@ -461,6 +735,15 @@ function decodeFullState(reader) {
   const walls = decodeWalls(reader);
   const mines = decodeMines(reader);
   const players = decodePlayers(reader);
+  const hostId = reader.readString();
+  const settings = decodeSettings(reader);
+  const zone = decodeZone(reader);
+  const winnerTeam = reader.readUint8();
+  const bases = decodeBases(reader);
+  const fishCarrier = reader.readString();
+  const hills = decodeHills(reader);
+  const pings = decodePings(reader);
+  const hazards = decodeHazards(reader);
 
   return {
     state: {
@@ -471,14 +754,10 @@ function decodeFullState(reader) {
       hidePhase,
       shootPhase,
       message,
-      seekerId,
-      bombHolder,
-      bombTimer,
+      seekerIds,
+      bombs,
       winnerId: winnerId || null,
       goldenChainActive,
-      statusEffect: statusType
-        ? { type: statusType, remaining: statusRemaining, playerId: statusPlayerId || undefined }
-        : null,
       fish,
       powerUp,
       powerUps,
@@ -487,7 +766,16 @@ function decodeFullState(reader) {
       players,
       serverTime,
       tickIndex,
-      roomName
+      roomName,
+      hostId,
+      settings,
+      zone,
+      winnerTeam,
+      bases,
+      fishCarrier,
+      hills,
+      pings,
+      hazards
     }
   };
 }
@@ -498,6 +786,7 @@ function decodePatch(reader) {
   const flags1 = reader.readUint8();
   const flags2 = reader.readUint8();
   const flags3 = reader.readUint8();
+  const flags4 = reader.readUint8();
   const patch = { serverTime, tickIndex };
 
   if (flags1 & (1 << 0)) patch.phase = reader.readString();
@@ -506,14 +795,6 @@ function decodePatch(reader) {
   if (flags1 & (1 << 3)) patch.message = reader.readString();
   if (flags1 & (1 << 4)) patch.winnerId = reader.readString();
   if (flags1 & (1 << 5)) patch.goldenChainActive = reader.readBool();
-  if (flags1 & (1 << 6)) {
-    const statusType = reader.readString();
-    const statusRemaining = reader.readFloat32();
-    const statusPlayerId = reader.readString();
-    patch.statusEffect = statusType
-      ? { type: statusType, remaining: statusRemaining, playerId: statusPlayerId || undefined }
-      : null;
-  }
   if (flags1 & (1 << 7)) {
     const fishType = reader.readUint8();
     patch.fish = {
@@ -541,7 +822,7 @@ function decodePatch(reader) {
     patch.powerUps = decodePowerUps(reader);
   }
   if (flags3 & (1 << 1)) {
-    patch.seekerId = reader.readString();
+    patch.seekerIds = decodeStrings(reader);
   }
   if (flags3 & (1 << 2)) {
     patch.hidePhase = reader.readString();
@@ -573,10 +854,34 @@ function decodePatch(reader) {
     patch.mode = reader.readString();
   }
   if (flags2 & (1 << 6)) {
-    patch.bombHolder = reader.readString();
+    patch.bombs = decodeBombs(reader);
+  }
+  if (flags3 & (1 << 4)) {
+    patch.hostId = reader.readString();
+  }
+  if (flags3 & (1 << 5)) {
+    patch.settings = decodeSettings(reader);
+  }
+  if (flags3 & (1 << 6)) {
+    patch.zone = decodeZone(reader);
+  }
+  if (flags3 & (1 << 7)) {
+    patch.winnerTeam = reader.readUint8();
+  }
+  if (flags4 & (1 << 0)) {
+    patch.bases = decodeBases(reader);
+  }
+  if (flags4 & (1 << 1)) {
+    patch.fishCarrier = reader.readString();
+  }
+  if (flags4 & (1 << 2)) {
+    patch.hills = decodeHills(reader);
+  }
+  if (flags4 & (1 << 3)) {
+    patch.pings = decodePings(reader);
   }
-  if (flags2 & (1 << 7)) {
-    patch.bombTimer = reader.readFloat32();
+  if (flags4 & (1 << 4)) {
+    patch.hazards = decodeHazards(reader);
   }
   return { patch };
 }
@@ -593,21 +898,25 @@ export function decodeStateFromBase64(payload) {
   return decodeFullState(reader);
 }
 
-export function encodeInputToBase64(playerId, vector, shoot = false) {
+export function encodeInputToBase64(playerId, vector, shoot = false, aim = null) {
   const writer = new BinaryWriter();
   writer.writeString(playerId || "");
   writer.writeFloat32(vector?.x || 0);
   writer.writeFloat32(vector?.y || 0);
   writer.writeBool(Boolean(shoot));
+  writer.writeFloat32(aim?.x || 0);
+  writer.writeFloat32(aim?.y || 0);
   return toBase64(writer.toUint8Array());
 }
 
-export function encodeInputToBuffer(playerId, vector, shoot = false) {
+export function encodeInputToBuffer(playerId, vector, shoot = false, aim = null) {
   const writer = new BinaryWriter();
   writer.writeString(playerId || "");
   writer.writeFloat32(vector?.x || 0);
   writer.writeFloat32(vector?.y || 0);
   writer.writeBool(Boolean(shoot));
+  writer.writeFloat32(aim?.x || 0);
+  writer.writeFloat32(aim?.y || 0);
   return writer.toUint8Array();
 }
 
@@ -619,6 +928,7 @@ export function decodeInputFromBase64(payload) {
   return {
     playerId: reader.readString(),
     vector: { x: reader.readFloat32(), y: reader.readFloat32() },
-    shoot: reader.readBool()
+    shoot: reader.readBool(),
+    aim: { x: reader.readFloat32(), y: reader.readFloat32() }
   };
 }
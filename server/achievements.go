@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type gameEvent struct {
+	Type     string
+	PlayerID string
+	Value    float64
+	Detail   string
+}
+
+type achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	check       func(t *achievementTracker, ev gameEvent) bool
+}
+
+type achievementUnlock struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlockedAt"`
+}
+
+// achievementTracker keeps the per-round counters that achievements need
+// on top of the single event being evaluated.
+type achievementTracker struct {
+	mode         string
+	fishCaught   map[string]int
+	powerUpsUsed map[string]int
+	kills        map[string]int
+	hidersFound  map[string]int
+}
+
+func newAchievementTracker(mode string) *achievementTracker {
+	return &achievementTracker{
+		mode:         mode,
+		fishCaught:   make(map[string]int),
+		powerUpsUsed: make(map[string]int),
+		kills:        make(map[string]int),
+		hidersFound:  make(map[string]int),
+	}
+}
+
+var achievements = []achievement{
+	{
+		ID:          "first-catch",
+		Title:       "Первый улов",
+		Description: "Поймать первую рыбку",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "fishCaught"
+		},
+	},
+	{
+		ID:          "bare-paws",
+		Title:       "Голыми лапами",
+		Description: "Поймать 5 рыбок за раунд без бонусов",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "fishCaught" && t.fishCaught[ev.PlayerID] >= 5 && t.powerUpsUsed[ev.PlayerID] == 0
+		},
+	},
+	{
+		ID:          "last-second",
+		Title:       "В последний момент",
+		Description: "Передать бомбу, когда до взрыва меньше секунды",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "bombPassed" && ev.Value < 1
+		},
+	},
+	{
+		ID:          "goose-disguise",
+		Title:       "Гусь вне подозрений",
+		Description: "Победить в прятках, притворившись гусем",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "roundWon" && t.mode == "hide-and-seek" && ev.Detail == "goose"
+		},
+	},
+	{
+		ID:          "keen-nose",
+		Title:       "Чуткий нос",
+		Description: "Найти трёх спрятавшихся за один раунд",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "hiderFound" && t.hidersFound[ev.PlayerID] >= 3
+		},
+	},
+	{
+		ID:          "triple-kill",
+		Title:       "Меткий стрелок",
+		Description: "Выбить трёх котиков за один раунд",
+		check: func(t *achievementTracker, ev gameEvent) bool {
+			return ev.Type == "playerKilled" && t.kills[ev.PlayerID] >= 3
+		},
+	},
+}
+
+func findAchievement(id string) (achievement, bool) {
+	for _, a := range achievements {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return achievement{}, false
+}
+
+func (t *achievementTracker) record(ev gameEvent) {
+	switch ev.Type {
+	case "fishCaught":
+		t.fishCaught[ev.PlayerID]++
+	case "powerUpCollected":
+		t.powerUpsUsed[ev.PlayerID]++
+	case "playerKilled":
+		t.kills[ev.PlayerID]++
+	case "hiderFound":
+		t.hidersFound[ev.PlayerID]++
+	}
+}
+
+func (t *achievementTracker) evaluate(ev gameEvent) []string {
+	t.record(ev)
+	unlocked := []string{}
+	for _, a := range achievements {
+		if a.check(t, ev) {
+			unlocked = append(unlocked, a.ID)
+		}
+	}
+	return unlocked
+}
+
+// emitEventLocked feeds a simulation event to the achievement tracker. Any
+// candidate unlocks are checked against the store outside of the room lock.
+func (r *room) emitEventLocked(ev gameEvent) {
+	if ev.PlayerID == "" {
+		return
+	}
+	if r.tracker == nil || r.tracker.mode != r.state.Mode {
+		r.tracker = newAchievementTracker(r.state.Mode)
+	}
+	if ids := r.tracker.evaluate(ev); len(ids) > 0 {
+		go r.unlockAchievements(ev.PlayerID, ids)
+	}
+}
+
+func (r *room) unlockAchievements(playerID string, ids []string) {
+	unlocked := r.server.unlockAchievements(playerID, ids)
+	if len(unlocked) == 0 {
+		return
+	}
+	r.mu.Lock()
+	conns := r.playerConnsLocked(playerID)
+	r.mu.Unlock()
+	for _, unlock := range unlocked {
+		data, _ := json.Marshal(wsMessage{Type: "achievement", Achievement: &unlock})
+		for _, client := range conns {
+			client.send(websocket.TextMessage, data)
+		}
+	}
+}
+
+// unlockAchievements only updates memory; unlocks reach data.json with the
+// next round's save.
+func (s *server) unlockAchievements(playerID string, ids []string) []achievementUnlock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owned := s.achievements[playerID]
+	if owned == nil {
+		owned = make(map[string]time.Time)
+		s.achievements[playerID] = owned
+	}
+	now := time.Now()
+	unlocked := []achievementUnlock{}
+	for _, id := range ids {
+		if _, ok := owned[id]; ok {
+			continue
+		}
+		a, ok := findAchievement(id)
+		if !ok {
+			continue
+		}
+		owned[id] = now
+		unlocked = append(unlocked, achievementUnlock{ID: a.ID, Title: a.Title, Description: a.Description, UnlockedAt: now})
+	}
+	return unlocked
+}
+
+func (s *server) handlePlayerAchievements(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	unlocked := []achievementUnlock{}
+	for _, a := range achievements {
+		if at, ok := s.achievements[id][a.ID]; ok {
+			unlocked = append(unlocked, achievementUnlock{ID: a.ID, Title: a.Title, Description: a.Description, UnlockedAt: at})
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, map[string]any{"playerId": id, "achievements": unlocked, "available": achievements})
+}
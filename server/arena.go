@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// hazardCell is a grid cell of collapsing floor. While Warning runs down
+// the cell is only marked; after that it eliminates every cat standing on it
+// for Remaining seconds.
+type hazardCell struct {
+	Row       int     `json:"row"`
+	Col       int     `json:"col"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Size      float64 `json:"size"`
+	Warning   float64 `json:"warning"`
+	Remaining float64 `json:"remaining"`
+}
+
+// wallSlide moves one generated wall segment to a neighbouring cell.
+type wallSlide struct {
+	index    int
+	from     wallSegment
+	to       wallSegment
+	progress float64
+}
+
+// setWallSegmentsLocked remembers the grid segments behind the generated
+// walls, which are always the last entries of state.Walls, and the cell
+// every cat must be able to reach.
+func (r *room) setWallSegmentsLocked(segments []wallSegment, objective gridCell) {
+	r.wallSegments = segments
+	r.wallObjective = objective
+	r.wallSlide = nil
+}
+
+// arenaBlockedGridLocked marks wall segments, cells too cramped for a cat,
+// floor hazards and any extra segments as impassable.
+func (r *room) arenaBlockedGridLocked(extra ...wallSegment) [][]bool {
+	world := r.currentWorldSize()
+	segments := append(append([]wallSegment{}, r.wallSegments...), extra...)
+	blocked := buildBlockedGridFromSegments(segments, gridDimension(world))
+	markCrampedCells(blocked, append(convertSegmentsToWalls(extra, world, r.wallThicknessRate()), r.state.Walls...), world)
+	r.markHazardCellsLocked(blocked)
+	return blocked
+}
+
+func (r *room) markHazardCellsLocked(blocked [][]bool) {
+	for _, h := range r.state.Hazards {
+		// Cells left over from a round with another world size are ignored.
+		if h.Row < len(blocked) && h.Col < len(blocked) {
+			blocked[h.Row][h.Col] = true
+		}
+	}
+}
+
+// arenaReachableLocked reports whether the arena stays in one piece: every
+// open cell, and so every cat, can still reach the objective.
+func (r *room) arenaReachableLocked(blocked [][]bool) bool {
+	return openCellsConnected(r.wallObjective, blocked)
+}
+
+// updateArenaEventsLocked advances running events and, every
+// ArenaEventInterval seconds, starts a new one.
+func (r *room) updateArenaEventsLocked() {
+	interval := r.state.Settings.ArenaEventInterval
+	if interval <= 0 {
+		return
+	}
+	r.updateWallSlideLocked()
+	r.updateHazardsLocked()
+	r.arenaEventTimer -= tickRate.Seconds()
+	if r.arenaEventTimer > 0 {
+		return
+	}
+	r.arenaEventTimer = interval
+	events := []func() bool{r.startWallSlideLocked, r.startFloorCollapseLocked, r.startMineWaveLocked}
+	rand.Shuffle(len(events), func(i, j int) { events[i], events[j] = events[j], events[i] })
+	for _, start := range events {
+		if start() {
+			return
+		}
+	}
+}
+
+func (r *room) startWallSlideLocked() bool {
+	if r.wallSlide != nil || len(r.wallSegments) == 0 {
+		return false
+	}
+	deltas := [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	for attempt := 0; attempt < 20; attempt++ {
+		index := rand.Intn(len(r.wallSegments))
+		from := r.wallSegments[index]
+		delta := deltas[rand.Intn(len(deltas))]
+		to := from
+		to.Row += delta[0]
+		to.Col += delta[1]
+		cells := getCellsForSegment(to.Row, to.Col, to.Length, to.Orientation)
+		if !segmentInsideGrid(cells, gridDimension(r.currentWorldSize())) {
+			continue
+		}
+		others := make([]wallSegment, 0, len(r.wallSegments)-1)
+		others = append(others, r.wallSegments[:index]...)
+		others = append(others, r.wallSegments[index+1:]...)
+		taken := buildBlockedGridFromSegments(others, gridDimension(r.currentWorldSize()))
+		r.markHazardCellsLocked(taken)
+		free := true
+		for _, cell := range cells {
+			if taken[cell.Row][cell.Col] {
+				free = false
+				break
+			}
+		}
+		// While sliding the wall covers both its old and new cells.
+		if !free || !r.arenaReachableLocked(r.arenaBlockedGridLocked(to)) {
+			continue
+		}
+		r.wallSlide = &wallSlide{index: index, from: from, to: to}
+		r.state.Message = "Стены сдвигаются!"
+		return true
+	}
+	return false
+}
+
+func segmentInsideGrid(cells []gridCell, n int) bool {
+	for _, cell := range cells {
+		if cell.Row < 0 || cell.Row >= n || cell.Col < 0 || cell.Col >= n {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *room) updateWallSlideLocked() {
+	slide := r.wallSlide
+	if slide == nil {
+		return
+	}
+	slide.progress = math.Min(slide.progress+tickRate.Seconds()/arenaSlideDuration, 1)
+	world := r.currentWorldSize()
+	rate := r.wallThicknessRate()
+	from := convertSegmentsToWalls([]wallSegment{slide.from}, world, rate)[0]
+	to := convertSegmentsToWalls([]wallSegment{slide.to}, world, rate)[0]
+	t := slide.progress
+	index := len(r.state.Walls) - len(r.wallSegments) + slide.index
+	r.state.Walls[index] = wall{
+		X:      from.X + (to.X-from.X)*t,
+		Y:      from.Y + (to.Y-from.Y)*t,
+		Width:  from.Width + (to.Width-from.Width)*t,
+		Height: from.Height + (to.Height-from.Height)*t,
+	}
+	r.handlePowerUpAfterWallChangeLocked()
+	r.resolvePlayersAfterWallChangeLocked()
+	if slide.progress >= 1 {
+		r.wallSegments[slide.index] = slide.to
+		r.wallSlide = nil
+	}
+}
+
+func (r *room) startFloorCollapseLocked() bool {
+	world := r.currentWorldSize()
+	n := gridDimension(world)
+	cellSize := world / float64(n)
+	catCells := make([]gridCell, 0, len(r.players))
+	for _, p := range r.players {
+		if p.Alive {
+			catCells = append(catCells, positionToGridCell(p.X, p.Y, world))
+		}
+	}
+	blocked := r.arenaBlockedGridLocked()
+	if r.wallSlide != nil {
+		blocked = r.arenaBlockedGridLocked(r.wallSlide.to)
+	}
+	count := 1 + rand.Intn(arenaCollapseCells)
+	var added []hazardCell
+	for attempt := 0; attempt < 40 && len(added) < count; attempt++ {
+		cell := gridCell{Row: rand.Intn(n), Col: rand.Intn(n)}
+		if blocked[cell.Row][cell.Col] || cell == r.wallObjective || containsCell(catCells, cell) {
+			continue
+		}
+		if r.cellHoldsObjectiveLocked(cell, cellSize) {
+			continue
+		}
+		blocked[cell.Row][cell.Col] = true
+		if !r.arenaReachableLocked(blocked) {
+			blocked[cell.Row][cell.Col] = false
+			continue
+		}
+		added = append(added, hazardCell{
+			Row:       cell.Row,
+			Col:       cell.Col,
+			X:         float64(cell.Col) * cellSize,
+			Y:         float64(cell.Row) * cellSize,
+			Size:      cellSize,
+			Warning:   arenaCollapseWarning,
+			Remaining: arenaCollapseDuration,
+		})
+	}
+	if len(added) == 0 {
+		return false
+	}
+	r.state.Hazards = append(r.state.Hazards, added...)
+	r.state.Message = "Пол рушится! Уходите с отмеченных клеток"
+	return true
+}
+
+// cellHoldsObjectiveLocked reports whether a grid cell overlaps a hill or a
+// team base; collapsing floor there would make the mode unplayable.
+func (r *room) cellHoldsObjectiveLocked(cell gridCell, cellSize float64) bool {
+	cx := (float64(cell.Col) + 0.5) * cellSize
+	cy := (float64(cell.Row) + 0.5) * cellSize
+	for _, hill := range r.state.Hills {
+		reach := (hill.Size + cellSize) / 2
+		if math.Abs(cx-hill.X) < reach && math.Abs(cy-hill.Y) < reach {
+			return true
+		}
+	}
+	area := wall{X: float64(cell.Col) * cellSize, Y: float64(cell.Row) * cellSize, Width: cellSize, Height: cellSize}
+	for _, base := range r.state.Bases {
+		if circleIntersectsRect(base.X, base.Y, base.Radius, area) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *room) updateHazardsLocked() {
+	if len(r.state.Hazards) == 0 {
+		return
+	}
+	active := make([]hazardCell, 0, len(r.state.Hazards))
+	for _, h := range r.state.Hazards {
+		if h.Warning > 0 {
+			h.Warning = math.Max(h.Warning-tickRate.Seconds(), 0)
+			active = append(active, h)
+			continue
+		}
+		h.Remaining -= tickRate.Seconds()
+		if h.Remaining <= 0 {
+			continue
+		}
+		area := wall{X: h.X, Y: h.Y, Width: h.Size, Height: h.Size}
+		for _, id := range sortedPlayerIDs(r.players) {
+			p := r.players[id]
+			if p.Alive && pointInsideRect(p.X, p.Y, area) {
+				r.recordEliminationLocked(p)
+				r.state.Message = fmt.Sprintf("%s провалился под пол!", fallbackName(p.Name))
+			}
+		}
+		active = append(active, h)
+	}
+	if len(active) == 0 {
+		active = nil
+	}
+	r.state.Hazards = active
+}
+
+func (r *room) startMineWaveLocked() bool {
+	if len(r.state.Mines) >= arenaMineMax {
+		return false
+	}
+	mines := append([]mine(nil), r.state.Mines...)
+	radius := mineSize / 2
+	margin := radius + mineMinDistance + 4
+	world := r.currentWorldSize()
+	added := 0
+	for attempt := 0; attempt < 200 && added < arenaMineWave && len(mines) < arenaMineMax; attempt++ {
+		x := margin + rand.Float64()*(world-margin*2)
+		y := margin + rand.Float64()*(world-margin*2)
+		if !r.isMinePositionValidLocked(x, y, radius, mines) {
+			continue
+		}
+		mines = append(mines, mine{X: x, Y: y, Size: mineSize})
+		added++
+	}
+	if added == 0 {
+		return false
+	}
+	r.state.Mines = mines
+	r.state.Message = "Волна мин!"
+	return true
+}
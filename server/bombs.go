@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"catgame/protocol"
+)
+
+// bomb is one live bomb in bomb-pass. Passes counts how often it changed
+// hands; in the hot potato variant every pass makes the fuse burn faster.
+type bomb struct {
+	Holder string  `json:"holder"`
+	Timer  float64 `json:"timer"`
+	Passes int     `json:"passes"`
+
+	// passedFrom and passedAt block an instant pass back to the giver.
+	passedFrom string
+	passedAt   time.Time
+}
+
+func toProtocolBombs(bombs []bomb) []protocol.Bomb {
+	out := make([]protocol.Bomb, len(bombs))
+	for i, b := range bombs {
+		out[i] = protocol.Bomb{Holder: b.Holder, Timer: b.Timer, Passes: b.Passes}
+	}
+	return out
+}
+
+// bombCountLocked scales the number of bombs with the living players: one
+// per playersPerBomb, but someone must always be free to receive a pass.
+func (r *room) bombCountLocked() int {
+	alive := r.countAlivePlayersLocked()
+	if alive == 0 {
+		return 0
+	}
+	count := alive / playersPerBomb
+	if count > alive-1 {
+		count = alive - 1
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+func (r *room) bombIndexLocked(playerID string) int {
+	for i, b := range r.state.Bombs {
+		if b.Holder == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *room) fuseRateLocked(b bomb) float64 {
+	if !r.state.Settings.HotPotato {
+		return 1
+	}
+	return math.Min(1+hotPotatoFuseStep*float64(b.Passes), hotPotatoMaxFuseRate)
+}
+
+// shortestFuseLocked is what the HUD shows as the round timer.
+func (r *room) shortestFuseLocked() float64 {
+	if len(r.state.Bombs) == 0 {
+		return r.state.Settings.BombTimerDuration
+	}
+	shortest := r.state.Bombs[0].Timer
+	for _, b := range r.state.Bombs[1:] {
+		shortest = math.Min(shortest, b.Timer)
+	}
+	return shortest
+}
+
+// fillBombsLocked drops bombs whose holder left or died and hands fresh
+// bombs to random free players until there are bombCountLocked of them.
+func (r *room) fillBombsLocked() {
+	kept := r.state.Bombs[:0]
+	for _, b := range r.state.Bombs {
+		if p, ok := r.players[b.Holder]; ok && p.Alive {
+			kept = append(kept, b)
+		}
+	}
+	r.state.Bombs = kept
+	want := r.bombCountLocked()
+	if len(r.state.Bombs) > want {
+		r.state.Bombs = r.state.Bombs[:want]
+	}
+
+	var free []*playerState
+	for _, id := range sortedPlayerIDs(r.players) {
+		if p := r.players[id]; p.Alive && r.bombIndexLocked(id) < 0 {
+			free = append(free, p)
+		}
+	}
+	rand.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	var names []string
+	for len(r.state.Bombs) < want && len(free) > 0 {
+		picked := free[0]
+		free = free[1:]
+		r.state.Bombs = append(r.state.Bombs, bomb{Holder: picked.ID, Timer: r.state.Settings.BombTimerDuration})
+		r.applyBombSlowdownLocked(picked.ID)
+		names = append(names, fallbackName(picked.Name))
+	}
+	switch {
+	case len(names) == 1:
+		r.state.Message = fmt.Sprintf("Бомба у %s!", names[0])
+	case len(names) > 1:
+		r.state.Message = fmt.Sprintf("Бомбы у: %s!", strings.Join(names, ", "))
+	}
+}
+
+func (r *room) handleBombTransferLocked() {
+	for i := range r.state.Bombs {
+		b := &r.state.Bombs[i]
+		holder, ok := r.players[b.Holder]
+		if !ok || !holder.Alive {
+			continue
+		}
+		for id, p := range r.players {
+			if id == holder.ID || !p.Alive || !r.canReceiveBombLocked(holder, p) || r.bombIndexLocked(id) >= 0 {
+				continue
+			}
+			dist := math.Hypot(holder.X-p.X, holder.Y-p.Y)
+			if dist > (holder.Size+p.Size)/2 {
+				continue
+			}
+			if p.ID == b.passedFrom && time.Since(b.passedAt) < time.Second {
+				continue
+			}
+			r.emitEventLocked(gameEvent{Type: "bombPassed", PlayerID: holder.ID, Value: b.Timer})
+			r.roundStatsLocked(holder.ID).BombsPassed++
+			b.Holder = p.ID
+			b.Timer = math.Max(b.Timer, 0) + r.state.Settings.BombTimerBonus
+			b.Passes++
+			b.passedFrom = holder.ID
+			b.passedAt = time.Now()
+			r.applyBombSlowdownLocked(p.ID)
+			r.state.Message = fmt.Sprintf("%s передал бомбу %s", fallbackName(holder.Name), fallbackName(p.Name))
+			break
+		}
+	}
+}
+
+// explodeBombsLocked blows up every bomb whose fuse ran out and returns the
+// announcement, or "" if nothing exploded. The blast eliminates cats within
+// the blast radius; a caught cat holding another bomb sets that one off too.
+func (r *room) explodeBombsLocked() string {
+	var queue []*playerState
+	for _, b := range r.state.Bombs {
+		if p, ok := r.players[b.Holder]; ok && p.Alive && b.Timer <= 0 {
+			queue = append(queue, p)
+		}
+	}
+	if len(queue) == 0 {
+		return ""
+	}
+	radius := r.state.Settings.BombBlastRadius
+	exploded := make(map[string]bool)
+	var holders, victims []string
+	for len(queue) > 0 {
+		center := queue[0]
+		queue = queue[1:]
+		if exploded[center.ID] {
+			continue
+		}
+		exploded[center.ID] = true
+		holders = append(holders, fallbackName(center.Name))
+		r.recordEliminationLocked(center)
+		r.roundStatsLocked(center.ID).TimesExploded++
+		if radius <= 0 {
+			continue
+		}
+		for _, id := range sortedPlayerIDs(r.players) {
+			p := r.players[id]
+			if !p.Alive || exploded[id] || !r.canDamageLocked(center, p) {
+				continue
+			}
+			if math.Hypot(center.X-p.X, center.Y-p.Y) > radius+p.Size/2 {
+				continue
+			}
+			if r.bombIndexLocked(id) >= 0 {
+				queue = append(queue, p)
+				continue
+			}
+			r.recordEliminationLocked(p)
+			victims = append(victims, fallbackName(p.Name))
+		}
+	}
+
+	kept := r.state.Bombs[:0]
+	for _, b := range r.state.Bombs {
+		if !exploded[b.Holder] {
+			kept = append(kept, b)
+		}
+	}
+	r.state.Bombs = kept
+
+	message := fmt.Sprintf("%s не успел избавиться от бомбы!", holders[0])
+	if len(holders) > 1 {
+		message = fmt.Sprintf("Цепной взрыв: %s!", strings.Join(holders, ", "))
+	}
+	if len(victims) > 0 {
+		message += fmt.Sprintf(" Взрывом задело: %s.", strings.Join(victims, ", "))
+	}
+	return message
+}
+
+func (r *room) updateBombPassLocked() {
+	if r.roundDecidedLocked() {
+		r.endRoundLocked("Раунд завершён")
+		return
+	}
+	r.tickBombSlowdownsLocked()
+	r.fillBombsLocked()
+	r.handleBombTransferLocked()
+	for i := range r.state.Bombs {
+		b := &r.state.Bombs[i]
+		b.Timer = math.Max(b.Timer-tickRate.Seconds()*r.fuseRateLocked(*b), 0)
+	}
+	blast := r.explodeBombsLocked()
+	if r.roundDecidedLocked() {
+		r.endRoundLocked(r.lastStandingMessageLocked())
+		return
+	}
+	if blast != "" {
+		r.state.Message = blast
+	}
+	message := r.state.Message
+	r.fillBombsLocked()
+	if blast != "" && r.state.Message != message {
+		r.state.Message = blast + " " + r.state.Message
+	}
+	r.state.Remaining = r.shortestFuseLocked()
+}
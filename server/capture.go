@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type teamBase struct {
+	Team   int     `json:"team"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Radius float64 `json:"radius"`
+}
+
+func (r *room) isCaptureMode() bool {
+	return r.state.Mode == "capture-the-fish"
+}
+
+// captureBaseCells places the two bases in the middle of the left and right
+// edges of an n×n wall grid, half a base grid cell in from the border.
+func captureBaseCells(n int) []gridCell {
+	edge := n / gridSize / 2
+	return []gridCell{
+		{Row: n / 2, Col: edge},
+		{Row: n / 2, Col: n - 1 - edge},
+	}
+}
+
+func (r *room) startCaptureRoundLocked() {
+	world := r.currentWorldSize()
+	n := gridDimension(world)
+	cellSize := world / float64(n)
+	baseCells := captureBaseCells(n)
+	r.state.Bases = make([]teamBase, len(baseCells))
+	for i, cell := range baseCells {
+		r.state.Bases[i] = teamBase{
+			Team:   i + 1,
+			X:      (float64(cell.Col) + 0.5) * cellSize,
+			Y:      (float64(cell.Row) + 0.5) * cellSize,
+			Radius: captureBaseRadius,
+		}
+	}
+	r.spawnAtBasesLocked()
+	r.buildCaptureArenaLocked(baseCells)
+	r.resetCaptureFishLocked()
+	r.state.Message = fmt.Sprintf("Принесите рыбку на свою базу! Игра до %d", captureScoreLimit)
+}
+
+func (r *room) spawnAtBasesLocked() {
+	perTeam := make(map[int]int)
+	for _, id := range sortedPlayerIDs(r.players) {
+		p := r.players[id]
+		base, ok := r.baseForTeamLocked(p.Team)
+		if !ok {
+			continue
+		}
+		offset := float64(perTeam[p.Team])
+		perTeam[p.Team]++
+		// Alternate above and below the base centre.
+		step := math.Ceil(offset/2) * catSize * 1.2
+		if int(offset)%2 == 1 {
+			step = -step
+		}
+		p.X = base.X
+		p.Y = base.Y + step
+	}
+	r.resolvePlayersAfterWallChangeLocked()
+}
+
+// buildCaptureArenaLocked keeps both bases free of walls and connected to
+// the fish spawn in the middle of the arena.
+func (r *room) buildCaptureArenaLocked(baseCells []gridCell) {
+	if m := r.activeMapLocked(); m != nil {
+		r.applyMapWallsLocked(m)
+		return
+	}
+	world := r.currentWorldSize()
+	layout := r.buildBoundaryWalls(world)
+	cells := append([]gridCell{}, baseCells...)
+	for _, p := range r.players {
+		cell := positionToGridCell(p.X, p.Y, world)
+		if !containsCell(cells, cell) {
+			cells = append(cells, cell)
+		}
+	}
+	n := gridDimension(world)
+	anchor := gridCell{Row: n / 2, Col: n / 2}
+	r.setWallSegmentsLocked(nil, anchor)
+	if candidate, segments := r.generateWallsLayoutForPlayers(cells, anchor, layout); candidate != nil {
+		layout = append(layout, candidate...)
+		r.setWallSegmentsLocked(segments, anchor)
+	}
+	r.state.Walls = layout
+	r.resolvePlayersAfterWallChangeLocked()
+}
+
+func (r *room) baseForTeamLocked(team int) (teamBase, bool) {
+	for _, base := range r.state.Bases {
+		if base.Team == team {
+			return base, true
+		}
+	}
+	return teamBase{}, false
+}
+
+func (r *room) resetCaptureFishLocked() {
+	world := r.currentWorldSize()
+	r.state.Fish = fishState{X: world / 2, Y: world / 2, Size: fishSize, Alive: true, Type: "normal", Direction: 1}
+	r.state.FishCarrier = ""
+	r.fishIdleTimer = 0
+	r.pickupCooldowns = make(map[string]float64)
+}
+
+func (r *room) captureSpeedMultiplierLocked(playerID string) float64 {
+	if r.isCaptureMode() && playerID == r.state.FishCarrier {
+		return captureCarrierSpeed
+	}
+	return 1
+}
+
+func (r *room) updateCaptureLocked() {
+	for id, remaining := range r.pickupCooldowns {
+		if remaining -= tickRate.Seconds(); remaining <= 0 {
+			delete(r.pickupCooldowns, id)
+		} else {
+			r.pickupCooldowns[id] = remaining
+		}
+	}
+
+	carrier, ok := r.players[r.state.FishCarrier]
+	if r.state.FishCarrier != "" && (!ok || !carrier.Alive) {
+		r.dropFishLocked(nil)
+		carrier = nil
+	}
+	if carrier == nil {
+		r.tickLooseFishLocked()
+		return
+	}
+
+	r.state.Fish.X = carrier.X
+	r.state.Fish.Y = carrier.Y
+	for _, p := range r.players {
+		if !p.Alive || p.Team == carrier.Team {
+			continue
+		}
+		if math.Hypot(p.X-carrier.X, p.Y-carrier.Y) <= (p.Size+carrier.Size)/2 {
+			r.dropFishLocked(p)
+			return
+		}
+	}
+	base, ok := r.baseForTeamLocked(carrier.Team)
+	if ok && math.Hypot(carrier.X-base.X, carrier.Y-base.Y) <= base.Radius {
+		r.scoreCaptureLocked(carrier)
+	}
+}
+
+func (r *room) tickLooseFishLocked() {
+	world := r.currentWorldSize()
+	if math.Hypot(r.state.Fish.X-world/2, r.state.Fish.Y-world/2) > 1 {
+		r.fishIdleTimer += tickRate.Seconds()
+		if r.fishIdleTimer >= captureFishReturn {
+			r.resetCaptureFishLocked()
+			r.state.Message = "Рыбка вернулась в центр"
+			return
+		}
+	}
+	for _, id := range sortedPlayerIDs(r.players) {
+		p := r.players[id]
+		if !p.Alive || r.pickupCooldowns[id] > 0 {
+			continue
+		}
+		if math.Hypot(p.X-r.state.Fish.X, p.Y-r.state.Fish.Y) <= fishCatchDistance {
+			r.state.FishCarrier = id
+			r.fishIdleTimer = 0
+			r.state.Message = fmt.Sprintf("%s схватил рыбку!", fallbackName(p.Name))
+			return
+		}
+	}
+}
+
+// dropFishLocked leaves the fish where the carrier was. The former carrier
+// cannot pick it straight back up.
+func (r *room) dropFishLocked(tagger *playerState) {
+	if carrier, ok := r.players[r.state.FishCarrier]; ok {
+		r.pickupCooldowns[carrier.ID] = captureDropCooldown
+		if tagger != nil {
+			r.state.Message = fmt.Sprintf("%s отобрал рыбку у %s", fallbackName(tagger.Name), fallbackName(carrier.Name))
+		}
+	}
+	r.state.FishCarrier = ""
+	r.fishIdleTimer = 0
+}
+
+func (r *room) scoreCaptureLocked(carrier *playerState) {
+	carrier.Score++
+	r.roundStatsLocked(carrier.ID).FishCaught++
+	r.resetCaptureFishLocked()
+	teamScore := r.teamScoresLocked()[carrier.Team]
+	r.state.Message = fmt.Sprintf("%s принёс рыбку команде %d (%d/%d)", fallbackName(carrier.Name), carrier.Team, teamScore, captureScoreLimit)
+	if teamScore >= captureScoreLimit {
+		r.endRoundLocked(fmt.Sprintf("Команда %d победила!", carrier.Team))
+	}
+}
+
+type captureMode struct{}
+
+func init() {
+	registerMode(captureMode{})
+}
+
+func (captureMode) Name() string { return "capture-the-fish" }
+
+func (captureMode) Defaults(settings *roomSettings) {
+	settings.WorldScale = captureWorldScale
+	settings.RoundDuration = captureRoundDuration
+}
+
+func (captureMode) WallParams() wallParams { return arenaWallParams }
+
+func (captureMode) ItemTypes() map[string]uint8 { return nil }
+
+func (captureMode) StartRound(r *room) {
+	r.clearModeEntitiesLocked()
+	r.startCaptureRoundLocked()
+}
+
+func (captureMode) Tick(r *room) {
+	r.state.Remaining -= tickRate.Seconds()
+	r.updatePlayersLocked()
+	r.updateCaptureLocked()
+	if r.state.Phase == "playing" && r.state.Remaining <= 0 {
+		r.endRoundLocked("Время вышло")
+	}
+}
+
+func (captureMode) Pickup(r *room, p *playerState) {}
+
+func (captureMode) Collide(r *room) {
+	r.resolvePlayerCollisionsLocked()
+}
+
+func (captureMode) Winner(r *room) string {
+	return r.topScorerLocked()
+}
+
+func (captureMode) MatchRule() matchRule { return matchRule{Min: 4, Ideal: 8, Max: 12} }
+
+// Capture-the-fish is always played by two teams.
+func (captureMode) Teams(settings roomSettings) teamRule { return teamRule{Count: 2} }
+
+func (captureMode) Ranking(r *room) [][]string { return r.scoreRankingLocked() }
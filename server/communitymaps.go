@@ -0,0 +1,459 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// communityMap is an arena uploaded through the map editor. Only approved
+// maps can be selected for rooms; moderators flip the flag. The uploader
+// gets a secret edit token; only its hash is stored. Players who finished a
+// round on the map may rate it; that list is kept in memory only.
+type communityMap struct {
+	Map           *arenaMap      `json:"map"`
+	EditTokenHash string         `json:"editTokenHash"`
+	Approved      bool           `json:"approved"`
+	Ratings       map[string]int `json:"ratings,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	played        map[string]bool
+}
+
+// communityMapSummary is what the map list shows.
+type communityMapSummary struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	WorldScale float64  `json:"worldScale"`
+	Modes      []string `json:"modes"`
+	Builtin    bool     `json:"builtin"`
+	Approved   bool     `json:"approved"`
+	Rating     float64  `json:"rating"`
+	Votes      int      `json:"votes"`
+}
+
+var communityMapIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+var (
+	errMapForbidden = errors.New("forbidden")
+	errMapNotPlayed = errors.New("finish a round on the map first")
+)
+
+func (c *communityMap) averageRating() float64 {
+	if len(c.Ratings) == 0 {
+		return 0
+	}
+	total := 0
+	for _, stars := range c.Ratings {
+		total += stars
+	}
+	return float64(total) / float64(len(c.Ratings))
+}
+
+func (c *communityMap) summary() communityMapSummary {
+	return communityMapSummary{
+		ID:         c.Map.ID,
+		Name:       c.Map.Name,
+		WorldScale: c.Map.WorldScale,
+		Modes:      c.Map.Modes,
+		Approved:   c.Approved,
+		Rating:     c.averageRating(),
+		Votes:      len(c.Ratings),
+	}
+}
+
+// validateCommunityMap runs the file checks plus limits for uploads. Spawns
+// keep the same wall margin the room uses when resolving cats.
+func validateCommunityMap(m *arenaMap) error {
+	if !communityMapIDPattern.MatchString(m.ID) {
+		return fmt.Errorf("map id must be 1-40 lowercase letters, digits or dashes")
+	}
+	if len([]rune(m.Name)) > communityMapNameLimit {
+		return fmt.Errorf("map name is too long")
+	}
+	if len(m.Walls) > communityMapWallLimit || len(m.Segments) > communityMapWallLimit {
+		return fmt.Errorf("map has too many walls")
+	}
+	if len(m.Spawns) > communityMapSpawnLimit || len(m.Mines) > communityMapSpawnLimit || len(m.ItemZones) > communityMapSpawnLimit {
+		return fmt.Errorf("map has too many spawns, mines or item zones")
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	for _, name := range m.Modes {
+		mode, _ := lookupMode(name)
+		walls := m.wallsFor(mode)
+		for i, sp := range m.Spawns {
+			if entityIntersectsWalls(&playerState{X: sp.X, Y: sp.Y, Size: catSize}, walls) {
+				return fmt.Errorf("spawn %d is too close to a wall in mode %s", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func isMapModerator(r *http.Request) bool {
+	token := os.Getenv("MAP_MODERATOR_TOKEN")
+	return token != "" && subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(token)) == 1
+}
+
+func newEditToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashEditToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// canEdit reports whether token is the map's edit token. Maps stored
+// without one can only be managed by moderators.
+func (c *communityMap) canEdit(token string) bool {
+	if c.EditTokenHash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.EditTokenHash), []byte(hashEditToken(token))) == 1
+}
+
+// saveCommunityMap stores a new map and returns its edit token, or replaces
+// a map whose edit token is given. Edited maps go back to the moderation
+// queue.
+func (s *server) saveCommunityMap(token string, m *arenaMap) (string, error) {
+	now := time.Now()
+	s.mapsMu.Lock()
+	if _, builtin := s.maps[m.ID]; builtin {
+		s.mapsMu.Unlock()
+		return "", fmt.Errorf("map id %q is taken", m.ID)
+	}
+	entry, exists := s.communityMaps[m.ID]
+	if exists && !entry.canEdit(token) {
+		s.mapsMu.Unlock()
+		return "", fmt.Errorf("map id %q is taken", m.ID)
+	}
+	issued := ""
+	if !exists {
+		var err error
+		if issued, err = newEditToken(); err != nil {
+			s.mapsMu.Unlock()
+			return "", err
+		}
+		entry = &communityMap{EditTokenHash: hashEditToken(issued), CreatedAt: now}
+		s.communityMaps[m.ID] = entry
+	}
+	entry.Map = m
+	entry.Approved = false
+	entry.Ratings = nil
+	entry.played = nil
+	entry.UpdatedAt = now
+	s.mapsMu.Unlock()
+	s.persistMaps()
+	return issued, nil
+}
+
+// persistMaps writes data.json. mapsMu must not be held: persistLocked
+// takes it after the server lock.
+func (s *server) persistMaps() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persistLocked()
+}
+
+// updateCommunityMap runs fn on a stored map under the maps lock and saves
+// the result.
+func (s *server) updateCommunityMap(id string, fn func(entry *communityMap) error) error {
+	s.mapsMu.Lock()
+	entry, ok := s.communityMaps[id]
+	if !ok {
+		s.mapsMu.Unlock()
+		return os.ErrNotExist
+	}
+	if err := fn(entry); err != nil {
+		s.mapsMu.Unlock()
+		return err
+	}
+	s.mapsMu.Unlock()
+	s.persistMaps()
+	return nil
+}
+
+func (s *server) deleteCommunityMap(id, token string, moderator bool) error {
+	s.mapsMu.Lock()
+	entry, ok := s.communityMaps[id]
+	if !ok {
+		s.mapsMu.Unlock()
+		return os.ErrNotExist
+	}
+	if !moderator && !entry.canEdit(token) {
+		s.mapsMu.Unlock()
+		return errMapForbidden
+	}
+	delete(s.communityMaps, id)
+	s.mapsMu.Unlock()
+	s.persistMaps()
+	return nil
+}
+
+// listMaps returns the built-in maps and the approved community maps, best
+// rated first. Moderators also see the queue.
+func (s *server) listMaps(moderator bool) []communityMapSummary {
+	s.mapsMu.RLock()
+	defer s.mapsMu.RUnlock()
+	list := make([]communityMapSummary, 0, len(s.maps)+len(s.communityMaps))
+	for _, m := range s.maps {
+		list = append(list, communityMapSummary{ID: m.ID, Name: m.Name, WorldScale: m.WorldScale, Modes: m.Modes, Builtin: true, Approved: true})
+	}
+	for _, entry := range s.communityMaps {
+		if entry.Approved || moderator {
+			list = append(list, entry.summary())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Builtin != list[j].Builtin {
+			return list[i].Builtin
+		}
+		if list[i].Rating != list[j].Rating {
+			return list[i].Rating > list[j].Rating
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func decodeLimited(w http.ResponseWriter, r *http.Request, payload any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, communityMapMaxBytes)
+	return json.NewDecoder(r.Body).Decode(payload)
+}
+
+func (s *server) handleMaps(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]any{"maps": s.listMaps(isMapModerator(r))})
+	case http.MethodPost:
+		var payload struct {
+			Map *arenaMap `json:"map"`
+		}
+		if err := decodeLimited(w, r, &payload); err != nil || payload.Map == nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		m := payload.Map
+		m.ID = strings.TrimSpace(m.ID)
+		m.Name = strings.TrimSpace(m.Name)
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		if m.WorldScale == 0 {
+			m.WorldScale = 1
+		}
+		if err := validateCommunityMap(m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token, err := s.saveCommunityMap(bearerToken(r), m)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		response := map[string]any{"status": "ok", "id": m.ID, "approved": false}
+		if token != "" {
+			response["editToken"] = token
+		}
+		writeJSON(w, response)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *server) handleMap(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.mapsMu.RLock()
+		var payload any
+		if m, ok := s.maps[id]; ok {
+			payload = map[string]any{"map": m, "builtin": true, "approved": true}
+		} else if entry, ok := s.communityMaps[id]; ok {
+			summary := entry.summary()
+			payload = map[string]any{"map": entry.Map, "approved": entry.Approved, "rating": summary.Rating, "votes": summary.Votes}
+		}
+		s.mapsMu.RUnlock()
+		if payload == nil {
+			http.Error(w, "map not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, payload)
+	case http.MethodDelete:
+		err := s.deleteCommunityMap(id, bearerToken(r), isMapModerator(r))
+		writeMapResult(w, err)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// recordMapPlayers allows the given players to rate a community map.
+func (s *server) recordMapPlayers(id string, playerIDs []string) {
+	s.mapsMu.Lock()
+	defer s.mapsMu.Unlock()
+	entry, ok := s.communityMaps[id]
+	if !ok {
+		return
+	}
+	if entry.played == nil {
+		entry.played = make(map[string]bool)
+	}
+	for _, playerID := range playerIDs {
+		entry.played[playerID] = true
+	}
+}
+
+// rateCommunityMap stores a player's stars for an approved map they have
+// finished a round on.
+func (s *server) rateCommunityMap(id, playerID string, stars int) error {
+	if stars < 1 || stars > 5 {
+		return fmt.Errorf("stars must be between 1 and 5")
+	}
+	return s.updateCommunityMap(id, func(entry *communityMap) error {
+		if !entry.Approved {
+			return errMapForbidden
+		}
+		if !entry.played[playerID] {
+			return errMapNotPlayed
+		}
+		if entry.Ratings == nil {
+			entry.Ratings = make(map[string]int)
+		}
+		entry.Ratings[playerID] = stars
+		return nil
+	})
+}
+
+// recordMapRoundLocked remembers who finished a round on the room's map:
+// only they may rate it, over the API or from their game connection.
+func (r *room) recordMapRoundLocked() {
+	if r.activeMapLocked() == nil {
+		return
+	}
+	if r.mapRounds == nil {
+		r.mapRounds = make(map[string]string)
+	}
+	ids := sortedPlayerIDs(r.players)
+	for _, id := range ids {
+		r.mapRounds[id] = r.state.Settings.Map
+	}
+	r.server.recordMapPlayers(r.state.Settings.Map, ids)
+}
+
+// rateMap rates the map the player last finished a round on in this room.
+func (r *room) rateMap(playerID string, stars int) {
+	r.mu.Lock()
+	mapID, played := r.mapRounds[playerID]
+	conns := r.playerConnsLocked(playerID)
+	r.mu.Unlock()
+	err := errMapNotPlayed
+	if played {
+		err = r.server.rateCommunityMap(mapID, playerID, stars)
+	}
+	if err == nil {
+		return
+	}
+	data, _ := json.Marshal(wsMessage{Type: "error", Error: "Не удалось оценить карту: " + err.Error()})
+	for _, client := range conns {
+		client.send(websocket.TextMessage, data)
+	}
+}
+
+func (s *server) handleMapRating(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var payload struct {
+		PlayerID string `json:"playerId"`
+		Stars    int    `json:"stars"`
+	}
+	if err := decodeLimited(w, r, &payload); err != nil || payload.PlayerID == "" {
+		http.Error(w, "playerId and stars required", http.StatusBadRequest)
+		return
+	}
+	writeMapResult(w, s.rateCommunityMap(r.PathValue("id"), payload.PlayerID, payload.Stars))
+}
+
+func (s *server) handleMapApproval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isMapModerator(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	var payload struct {
+		Approved bool `json:"approved"`
+	}
+	if err := decodeLimited(w, r, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	err := s.updateCommunityMap(r.PathValue("id"), func(entry *communityMap) error {
+		entry.Approved = payload.Approved
+		return nil
+	})
+	writeMapResult(w, err)
+}
+
+func writeMapResult(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, map[string]string{"status": "ok"})
+	case errors.Is(err, os.ErrNotExist):
+		http.Error(w, "map not found", http.StatusNotFound)
+	case errors.Is(err, errMapForbidden):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case errors.Is(err, errMapNotPlayed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// loadCommunityMaps keeps the stored maps that still pass validation, e.g.
+// after a mode was removed.
+func loadCommunityMaps(stored map[string]*communityMap) map[string]*communityMap {
+	maps := make(map[string]*communityMap)
+	for id, entry := range stored {
+		if entry == nil || entry.Map == nil || entry.Map.ID != id {
+			continue
+		}
+		if err := validateCommunityMap(entry.Map); err != nil {
+			log.Printf("skipping stored map %s: %v", id, err)
+			continue
+		}
+		maps[id] = entry
+	}
+	return maps
+}
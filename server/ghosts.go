@@ -0,0 +1,82 @@
+package main
+
+import "math"
+
+// In bomb-pass eliminated cats keep playing as ghosts. Ghosts fly through
+// walls, never collide, pass or receive bombs, and can haunt a nearby cat:
+// a bomb holder gets marked for everyone to see, anyone else is slowed.
+// The haunt cooldown lives in AbilityCD; a haunted cat is then immune to
+// every ghost for a while so that several ghosts cannot chain-haunt it.
+
+func (r *room) isGhostLocked(p *playerState) bool {
+	return r.isBombMode() && r.state.Phase == "playing" && !p.Alive
+}
+
+func (r *room) updateGhostsLocked() {
+	for id, remaining := range r.hauntImmunity {
+		if remaining -= tickRate.Seconds(); remaining <= 0 {
+			delete(r.hauntImmunity, id)
+		} else {
+			r.hauntImmunity[id] = remaining
+		}
+	}
+	world := r.currentWorldSize()
+	for id, p := range r.players {
+		if !r.isGhostLocked(p) {
+			continue
+		}
+		input := r.inputs[id]
+		speed := catSpeed * tickRate.Seconds() * ghostSpeedMultiplier
+		p.X = clampFloat(p.X+input.X*speed, p.Size/2, world-p.Size/2)
+		p.Y = clampFloat(p.Y+input.Y*speed, p.Size/2, world-p.Size/2)
+		p.Moving = math.Abs(input.X) > 0.01 || math.Abs(input.Y) > 0.01
+		if p.Moving {
+			p.Facing = 1
+			if input.X < -0.01 {
+				p.Facing = -1
+			}
+			p.StepAccum += tickRate.Seconds() * 4
+			p.WalkCycle = math.Mod(p.StepAccum, 1)
+		}
+	}
+}
+
+// nearestHauntableLocked returns the closest living cat within reach of p
+// that is not immune to haunting.
+func (r *room) nearestHauntableLocked(p *playerState, reach float64) *playerState {
+	var nearest *playerState
+	best := math.Inf(1)
+	for _, id := range sortedPlayerIDs(r.players) {
+		target := r.players[id]
+		if !target.Alive || r.hauntImmunity[id] > 0 {
+			continue
+		}
+		dist := math.Hypot(p.X-target.X, p.Y-target.Y)
+		if dist <= reach+target.Size/2 && dist < best {
+			nearest = target
+			best = dist
+		}
+	}
+	return nearest
+}
+
+func (r *room) haunt(playerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.players[playerID]
+	if !ok || !r.isGhostLocked(p) || p.AbilityCD > 0 {
+		return
+	}
+	target := r.nearestHauntableLocked(p, ghostHauntRange)
+	if target == nil {
+		return
+	}
+	p.AbilityCD = ghostHauntCooldown
+	r.hauntImmunity[target.ID] = ghostHauntImmunity
+	if r.bombIndexLocked(target.ID) >= 0 {
+		addStatusEffect(target, "marked")
+	} else {
+		addStatusEffect(target, "haunted")
+	}
+	r.emitEventLocked(gameEvent{Type: "haunt", PlayerID: p.ID, Detail: target.ID})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// soundPing marks the rough spot of a hider's taunt for the seeker.
+type soundPing struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Remaining float64 `json:"remaining"`
+}
+
+// In hide-and-seek AbilityCD freezes the seeker after touching a decoy and
+// blocks a hider's next taunt, Charges counts the re-disguises a hider has
+// left.
+
+func (r *room) isSeekerLocked(playerID string) bool {
+	for _, id := range r.state.SeekerIDs {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// bestSeekerLocked returns the seeker who found the most hiders.
+func (r *room) bestSeekerLocked() string {
+	best := ""
+	for _, id := range r.state.SeekerIDs {
+		p, ok := r.players[id]
+		if !ok {
+			continue
+		}
+		if best == "" || p.Score > r.players[best].Score {
+			best = id
+		}
+	}
+	return best
+}
+
+// endIfSideWipedOutLocked ends the round once arena events leave no hider
+// or no seeker standing. A room with a single cat plays on.
+func (r *room) endIfSideWipedOutLocked() bool {
+	if len(r.players) < 2 {
+		return false
+	}
+	seekers := 0
+	for _, id := range r.state.SeekerIDs {
+		if p, ok := r.players[id]; ok && p.Alive {
+			seekers++
+		}
+	}
+	switch {
+	case r.countRemainingHidersLocked() == 0:
+		r.state.WinnerID = r.bestSeekerLocked()
+		r.endRoundLocked("Спрятавшихся котиков не осталось!")
+	case seekers == 0:
+		r.state.WinnerID = r.pickAliveHiderLocked()
+		r.endRoundLocked("Ведущих не осталось — спрятавшиеся победили!")
+	default:
+		return false
+	}
+	return true
+}
+
+func (r *room) startHideSeekHidersLocked() {
+	r.probedProps = make(map[string]map[int]bool)
+	for id, p := range r.players {
+		if !r.isSeekerLocked(id) {
+			p.Charges = hideSeekRedisguises
+		}
+	}
+}
+
+func (r *room) touchingPropLocked(p *playerState) (powerUpState, bool) {
+	for _, item := range r.state.PowerUps {
+		if item.Active && math.Hypot(p.X-item.X, p.Y-item.Y) <= (p.Size+item.Size)/2 {
+			return item, true
+		}
+	}
+	return powerUpState{}, false
+}
+
+// probeDecoysLocked penalises a seeker for inspecting a real prop: the
+// seeker is frozen for a moment and the search timer loses a few seconds.
+// A prop only counts again once the seeker has stepped away from it.
+func (r *room) probeDecoysLocked(seeker *playerState) {
+	touching := make(map[int]bool)
+	for i, item := range r.state.PowerUps {
+		if item.Active && math.Hypot(seeker.X-item.X, seeker.Y-item.Y) <= (seeker.Size+item.Size)/2 {
+			touching[i] = true
+		}
+	}
+	for i := range touching {
+		if r.probedProps[seeker.ID][i] || seeker.AbilityCD > 0 {
+			continue
+		}
+		seeker.AbilityCD = hideSeekProbeCooldown
+		r.state.Remaining = math.Max(r.state.Remaining-hideSeekProbePenalty, 0)
+		r.state.Message = fmt.Sprintf("%s проверил обычный предмет: −%s", fallbackName(seeker.Name), formatSecondsRu(hideSeekProbePenalty))
+		break
+	}
+	r.probedProps[seeker.ID] = touching
+}
+
+// redisguise lets a hider swap to the prop they are touching during the
+// search phase while charges remain.
+func (r *room) redisguise(playerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.players[playerID]
+	if !ok || !r.isHideSeekMode() || r.state.Phase != "playing" || r.state.HidePhase != "seeking" {
+		return
+	}
+	if !p.Alive || r.isSeekerLocked(p.ID) || p.Charges <= 0 {
+		return
+	}
+	item, ok := r.touchingPropLocked(p)
+	if !ok || item.Type == p.Disguise {
+		return
+	}
+	p.Disguise = item.Type
+	p.Size = item.Size
+	p.Charges--
+}
+
+// taunt trades safety for points: the hider scores, and the seeker sees a
+// ping close to, but not exactly at, the hider.
+func (r *room) taunt(playerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.players[playerID]
+	if !ok || !r.isHideSeekMode() || r.state.Phase != "playing" || r.state.HidePhase != "seeking" {
+		return
+	}
+	if !p.Alive || r.isSeekerLocked(p.ID) || p.AbilityCD > 0 {
+		return
+	}
+	p.AbilityCD = hideSeekTauntCooldown
+	p.Score += hideSeekTauntPoints
+	angle := rand.Float64() * 2 * math.Pi
+	offset := rand.Float64() * hideSeekPingJitter
+	world := r.currentWorldSize()
+	r.state.Pings = append(r.state.Pings, soundPing{
+		X:         clampFloat(p.X+math.Cos(angle)*offset, 0, world),
+		Y:         clampFloat(p.Y+math.Sin(angle)*offset, 0, world),
+		Remaining: hideSeekPingLifetime,
+	})
+	r.emitEventLocked(gameEvent{Type: "taunt", PlayerID: p.ID})
+}
+
+func (r *room) updatePingsLocked() {
+	if len(r.state.Pings) == 0 {
+		return
+	}
+	remaining := make([]soundPing, 0, len(r.state.Pings))
+	for _, ping := range r.state.Pings {
+		ping.Remaining -= tickRate.Seconds()
+		if ping.Remaining > 0 {
+			remaining = append(remaining, ping)
+		}
+	}
+	r.state.Pings = remaining
+}
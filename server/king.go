@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+type hillZone struct {
+	Row       int     `json:"row"`
+	Col       int     `json:"col"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Size      float64 `json:"size"`
+	Owner     string  `json:"owner,omitempty"`
+	Contested bool    `json:"contested,omitempty"`
+}
+
+func (r *room) isKingMode() bool {
+	return r.state.Mode == "king-of-the-hill"
+}
+
+func (r *room) startKingRoundLocked() {
+	r.hillPoints = make(map[string]float64)
+	r.knockback = make(map[string]vector)
+	r.buildArenaWithWallsLocked()
+	r.rotateHillsLocked()
+	r.state.Message = "Удерживайте холмы в одиночку, чтобы получать очки!"
+}
+
+// rotateHillsLocked moves the hills to new cells. Only open cells, clear of
+// collapsing floor and reachable from every cat's current position, are
+// eligible.
+func (r *room) rotateHillsLocked() {
+	r.hillTimer = hillRotateInterval
+	world := r.currentWorldSize()
+	n := gridDimension(world)
+	cellSize := world / float64(n)
+	blocked := r.arenaBlockedGridLocked()
+	starts := []gridCell{}
+	for _, p := range r.players {
+		starts = append(starts, positionToGridCell(p.X, p.Y, world))
+	}
+
+	previous := make(map[string]struct{}, len(r.state.Hills))
+	for _, hill := range r.state.Hills {
+		previous[cellKey(gridCell{Row: hill.Row, Col: hill.Col})] = struct{}{}
+	}
+	candidates := []gridCell{}
+	for row := 1; row < n-1; row++ {
+		for col := 1; col < n-1; col++ {
+			cell := gridCell{Row: row, Col: col}
+			if blocked[row][col] || containsCell(starts, cell) {
+				continue
+			}
+			if _, ok := previous[cellKey(cell)]; ok {
+				continue
+			}
+			reachable := true
+			for _, start := range starts {
+				if !isPathAvailable(start, cell, blocked) {
+					reachable = false
+					break
+				}
+			}
+			if reachable {
+				candidates = append(candidates, cell)
+			}
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	count := clampInt(1+len(r.players)/3, 1, hillMaxCount)
+	hills := make([]hillZone, 0, count)
+	// Hills keep the size of a base grid cell however fine the wall grid is.
+	hillSize := world / gridSize
+	for _, cell := range candidates {
+		if len(hills) == count {
+			break
+		}
+		x := (float64(cell.Col) + 0.5) * cellSize
+		y := (float64(cell.Row) + 0.5) * cellSize
+		adjacent := false
+		for _, hill := range hills {
+			if math.Hypot(hill.X-x, hill.Y-y) < hillSize*2.5 {
+				adjacent = true
+				break
+			}
+		}
+		if adjacent || r.hillOverHazardLocked(x, y, hillSize) {
+			continue
+		}
+		hills = append(hills, hillZone{Row: cell.Row, Col: cell.Col, X: x, Y: y, Size: hillSize})
+	}
+	if len(hills) > 0 || len(r.state.Hills) == 0 {
+		r.state.Hills = hills
+	}
+}
+
+// hillOverHazardLocked reports whether a hill at (x, y) would reach into
+// collapsing floor; hills are wider than a cell on fine wall grids.
+func (r *room) hillOverHazardLocked(x, y, size float64) bool {
+	for _, h := range r.state.Hazards {
+		reach := (size + h.Size) / 2
+		if math.Abs(x-(h.X+h.Size/2)) < reach && math.Abs(y-(h.Y+h.Size/2)) < reach {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *room) updateHillsLocked() {
+	r.hillTimer -= tickRate.Seconds()
+	if r.hillTimer <= 0 {
+		r.rotateHillsLocked()
+		r.state.Message = "Холмы переместились!"
+	}
+	for i := range r.state.Hills {
+		hill := &r.state.Hills[i]
+		occupants := []*playerState{}
+		for _, p := range r.players {
+			if p.Alive && math.Abs(p.X-hill.X) <= hill.Size/2 && math.Abs(p.Y-hill.Y) <= hill.Size/2 {
+				occupants = append(occupants, p)
+			}
+		}
+		hill.Contested = len(occupants) > 1
+		hill.Owner = ""
+		if len(occupants) != 1 {
+			continue
+		}
+		owner := occupants[0]
+		hill.Owner = owner.ID
+		r.hillPoints[owner.ID] += hillPointsPerSecond * tickRate.Seconds()
+		if whole := math.Floor(r.hillPoints[owner.ID]); whole >= 1 {
+			r.hillPoints[owner.ID] -= whole
+			owner.Score += int(whole)
+		}
+	}
+}
+
+// knockbackStepLocked returns how far a cat's remaining knockback moves it
+// this tick; the velocity fades out over a few ticks.
+func (r *room) knockbackStepLocked(p *playerState) vector {
+	kb, ok := r.knockback[p.ID]
+	if !ok {
+		return vector{}
+	}
+	step := vector{X: kb.X * tickRate.Seconds(), Y: kb.Y * tickRate.Seconds()}
+	kb.X *= hillKnockbackDecay
+	kb.Y *= hillKnockbackDecay
+	if math.Hypot(kb.X, kb.Y) < 5 {
+		delete(r.knockback, p.ID)
+	} else {
+		r.knockback[p.ID] = kb
+	}
+	return step
+}
+
+// resolveKnockbackCollisionsLocked bounces colliding cats apart before the
+// regular overlap resolution separates them.
+func (r *room) resolveKnockbackCollisionsLocked() {
+	players := r.alivePlayersLocked()
+	for i := 0; i < len(players); i++ {
+		for j := i + 1; j < len(players); j++ {
+			a, b := players[i], players[j]
+			dx, dy := b.X-a.X, b.Y-a.Y
+			dist := math.Hypot(dx, dy)
+			if dist >= (a.Size+b.Size)/2 {
+				continue
+			}
+			if dist == 0 {
+				dx, dy, dist = 1, 0, 1
+			}
+			nx, ny := dx/dist, dy/dist
+			r.knockback[a.ID] = vector{X: -nx * hillKnockbackSpeed, Y: -ny * hillKnockbackSpeed}
+			r.knockback[b.ID] = vector{X: nx * hillKnockbackSpeed, Y: ny * hillKnockbackSpeed}
+		}
+	}
+	r.resolvePlayerCollisionsLocked()
+}
+
+func (r *room) kingRoundMessageLocked() string {
+	if best, ok := r.players[r.bestPlayerIDLocked()]; ok {
+		return fmt.Sprintf("Царь горы: %s", fallbackName(best.Name))
+	}
+	return "Раунд завершён"
+}
+
+type kingMode struct{}
+
+func init() {
+	registerMode(kingMode{})
+}
+
+func (kingMode) Name() string { return "king-of-the-hill" }
+
+func (kingMode) Defaults(settings *roomSettings) {
+	settings.WorldScale = kingWorldScale
+	settings.RoundDuration = kingRoundDuration
+}
+
+func (kingMode) WallParams() wallParams { return arenaWallParams }
+
+func (kingMode) ItemTypes() map[string]uint8 { return nil }
+
+func (kingMode) StartRound(r *room) {
+	r.clearModeEntitiesLocked()
+	r.startKingRoundLocked()
+}
+
+func (kingMode) Tick(r *room) {
+	r.state.Remaining -= tickRate.Seconds()
+	r.updatePlayersLocked()
+	r.updateHillsLocked()
+	if r.state.Remaining <= 0 {
+		r.endRoundLocked(r.kingRoundMessageLocked())
+	}
+}
+
+func (kingMode) Pickup(r *room, p *playerState) {}
+
+func (kingMode) Collide(r *room) {
+	r.resolveKnockbackCollisionsLocked()
+}
+
+func (kingMode) Winner(r *room) string {
+	return r.topScorerLocked()
+}
+
+func (kingMode) MatchRule() matchRule { return matchRule{Min: 2, Ideal: 6, Max: 12} }
+
+func (kingMode) Teams(settings roomSettings) teamRule { return teamRule{} }
+
+func (kingMode) Ranking(r *room) [][]string { return r.scoreRankingLocked() }
@@ -21,7 +21,9 @@ import (
 func quantizeStateForSend(state *gameState) {
 	state.Countdown = quantizeSeconds(state.Countdown)
 	state.Remaining = quantizeSeconds(state.Remaining)
-	state.BombTimer = quantizeSeconds(state.BombTimer)
+	for i := range state.Bombs {
+		state.Bombs[i].Timer = quantizeSeconds(state.Bombs[i].Timer)
+	}
 
 	state.Fish.X = quantizeCoord(state.Fish.X)
 	state.Fish.Y = quantizeCoord(state.Fish.Y)
@@ -38,8 +40,25 @@ func quantizeStateForSend(state *gameState) {
 		state.PowerUps[i].Remaining = quantizeSeconds(state.PowerUps[i].Remaining)
 	}
 
-	if state.Status != nil {
-		state.Status.Remaining = quantizeSeconds(state.Status.Remaining)
+	for i := range state.Pings {
+		state.Pings[i].X = quantizeCoord(state.Pings[i].X)
+		state.Pings[i].Y = quantizeCoord(state.Pings[i].Y)
+		state.Pings[i].Remaining = quantizeSeconds(state.Pings[i].Remaining)
+	}
+	for i := range state.Hazards {
+		state.Hazards[i].Warning = quantizeSeconds(state.Hazards[i].Warning)
+		state.Hazards[i].Remaining = quantizeSeconds(state.Hazards[i].Remaining)
+	}
+	for i := range state.Hills {
+		state.Hills[i].X = quantizeCoord(state.Hills[i].X)
+		state.Hills[i].Y = quantizeCoord(state.Hills[i].Y)
+		state.Hills[i].Size = quantizeCoord(state.Hills[i].Size)
+	}
+	if state.Zone != nil {
+		state.Zone.X = quantizeCoord(state.Zone.X)
+		state.Zone.Y = quantizeCoord(state.Zone.Y)
+		state.Zone.Radius = quantizeCoord(state.Zone.Radius)
+		state.Zone.TargetRadius = quantizeCoord(state.Zone.TargetRadius)
 	}
 
 	for i := range state.Walls {
@@ -68,12 +87,16 @@ func quantizeStateForSend(state *gameState) {
 	}
 }
 
-func decodeInputBuffer(data []byte) (*string, *vector, *bool) {
-	id, vec, shoot := protocol.DecodeInputBuffer(data)
+func decodeInputBuffer(data []byte) (*string, *vector, *bool, *vector) {
+	id, vec, shoot, aim := protocol.DecodeInputBuffer(data)
 	if id == nil || vec == nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
-	return id, &vector{X: vec.X, Y: vec.Y}, shoot
+	var aimVec *vector
+	if aim != nil {
+		aimVec = &vector{X: aim.X, Y: aim.Y}
+	}
+	return id, &vector{X: vec.X, Y: vec.Y}, shoot, aimVec
 }
 
 func toProtocolPlayerState(p *playerState) protocol.PlayerState {
@@ -94,10 +117,17 @@ func toProtocolPlayerState(p *playerState) protocol.PlayerState {
 		WalkCycle:  p.WalkCycle,
 		StepAccum:  p.StepAccum,
 		Score:      p.Score,
+		Team:       p.Team,
 		Health:     p.Health,
+		Armor:      p.Armor,
 		Weapon:     p.Weapon,
+		Ammo:       p.Ammo,
+		Cooldown:   p.Cooldown,
+		Charges:    p.Charges,
+		AbilityCD:  p.AbilityCD,
 		Appearance: appearance,
 		Disguise:   p.Disguise,
+		Effects:    toProtocolEffects(p.Effects),
 	}
 }
 
@@ -122,36 +152,68 @@ func toProtocolGameState(state gameState) protocol.GameState {
 	for i, s := range state.Shots {
 		shots[i] = protocol.ShotEvent{ShooterID: s.ShooterID, FromX: s.FromX, FromY: s.FromY, ToX: s.ToX, ToY: s.ToY, Remaining: s.Remaining}
 	}
-	var status *protocol.StatusEffect
-	if state.Status != nil {
-		status = &protocol.StatusEffect{Type: state.Status.Type, Remaining: state.Status.Remaining, PlayerID: state.Status.PlayerID}
+	bases := make([]protocol.TeamBase, len(state.Bases))
+	for i, base := range state.Bases {
+		bases[i] = protocol.TeamBase(base)
+	}
+	hills := make([]protocol.HillZone, len(state.Hills))
+	for i, hill := range state.Hills {
+		hills[i] = protocol.HillZone(hill)
+	}
+	pings := make([]protocol.SoundPing, len(state.Pings))
+	for i, ping := range state.Pings {
+		pings[i] = protocol.SoundPing(ping)
+	}
+	hazards := make([]protocol.HazardCell, len(state.Hazards))
+	for i, hazard := range state.Hazards {
+		hazards[i] = protocol.HazardCell(hazard)
+	}
+	var zone *protocol.SafeZone
+	if state.Zone != nil {
+		protoZone := protocol.SafeZone(*state.Zone)
+		zone = &protoZone
 	}
 
 	return protocol.GameState{
-		RoomName:   state.RoomName,
-		Mode:       state.Mode,
-		Phase:      state.Phase,
-		Countdown:  state.Countdown,
-		Remaining:  state.Remaining,
-		HidePhase:  state.HidePhase,
-		ShootPhase: state.ShootPhase,
-		Message:    state.Message,
-		SeekerID:   state.SeekerID,
-		BombHolder: state.BombHolder,
-		BombTimer:  state.BombTimer,
-		Players:    players,
-		Fish:       protocol.FishState(state.Fish),
-		Walls:      walls,
-		Mines:      mines,
-		PowerUp:    protocol.PowerUpState(state.PowerUp),
-		PowerUps:   powerUps,
-		Shots:      shots,
-		Status:     status,
-		WinnerID:   state.WinnerID,
-		Golden:     state.Golden,
-		TickIndex:  state.TickIndex,
-		ServerTime: state.ServerTime,
-	}
+		RoomName:    state.RoomName,
+		Mode:        state.Mode,
+		Phase:       state.Phase,
+		Countdown:   state.Countdown,
+		Remaining:   state.Remaining,
+		HidePhase:   state.HidePhase,
+		ShootPhase:  state.ShootPhase,
+		Message:     state.Message,
+		SeekerIDs:   append([]string(nil), state.SeekerIDs...),
+		Bombs:       toProtocolBombs(state.Bombs),
+		Players:     players,
+		Fish:        protocol.FishState(state.Fish),
+		Walls:       walls,
+		Mines:       mines,
+		PowerUp:     protocol.PowerUpState(state.PowerUp),
+		PowerUps:    powerUps,
+		Shots:       shots,
+		Zone:        zone,
+		Bases:       bases,
+		FishCarrier: state.FishCarrier,
+		Hills:       hills,
+		Pings:       pings,
+		Hazards:     hazards,
+		WinnerID:    state.WinnerID,
+		WinnerTeam:  state.WinnerTeam,
+		Golden:      state.Golden,
+		HostID:      state.HostID,
+		Settings:    protocol.RoomSettings(state.Settings),
+		TickIndex:   state.TickIndex,
+		ServerTime:  state.ServerTime,
+	}
+}
+
+func toProtocolEffects(effects []statusEffect) []protocol.StatusEffect {
+	protoEffects := make([]protocol.StatusEffect, len(effects))
+	for i, effect := range effects {
+		protoEffects[i] = protocol.StatusEffect(effect)
+	}
+	return protoEffects
 }
 
 func toProtocolPlayerPatch(p playerPatch) protocol.PlayerPatch {
@@ -167,6 +229,7 @@ func toProtocolPlayerPatch(p playerPatch) protocol.PlayerPatch {
 	protoPatch.WalkCycle = p.WalkCycle
 	protoPatch.StepAccum = p.StepAccum
 	protoPatch.Score = p.Score
+	protoPatch.Team = p.Team
 	if len(p.Appearance) > 0 {
 		appearance := stringifyAppearance(p.Appearance)
 		if len(appearance) > 300 {
@@ -175,11 +238,19 @@ func toProtocolPlayerPatch(p playerPatch) protocol.PlayerPatch {
 		protoPatch.Appearance = &appearance
 	}
 	protoPatch.Health = p.Health
+	protoPatch.Armor = p.Armor
 	protoPatch.Weapon = p.Weapon
+	protoPatch.Ammo = p.Ammo
+	protoPatch.Cooldown = p.Cooldown
+	protoPatch.Charges = p.Charges
+	protoPatch.AbilityCD = p.AbilityCD
 	if p.Disguise != nil {
 		disguise := *p.Disguise
 		protoPatch.Disguise = &disguise
 	}
+	if p.Effects != nil {
+		protoPatch.Effects = toProtocolEffects(*p.Effects)
+	}
 	return protoPatch
 }
 
@@ -193,18 +264,51 @@ func toProtocolStatePatch(patch *statePatch) *protocol.StatePatch {
 	protoPatch.Countdown = patch.Countdown
 	protoPatch.Remaining = patch.Remaining
 	protoPatch.Message = patch.Message
-	protoPatch.SeekerID = patch.SeekerID
-	protoPatch.BombHolder = patch.BombHolder
-	protoPatch.BombTimer = patch.BombTimer
+	protoPatch.SeekerIDs = patch.SeekerIDs
+	if patch.Bombs != nil {
+		protoPatch.Bombs = toProtocolBombs(patch.Bombs)
+	}
 	protoPatch.WinnerID = patch.WinnerID
+	protoPatch.WinnerTeam = patch.WinnerTeam
 	protoPatch.Golden = patch.Golden
 	protoPatch.ShootPhase = patch.ShootPhase
-	if patch.Status != nil {
-		protoPatch.Status = &protocol.StatusEffect{
-			Type:      patch.Status.Type,
-			Remaining: patch.Status.Remaining,
-			PlayerID:  patch.Status.PlayerID,
+	protoPatch.HostID = patch.HostID
+	if patch.Settings != nil {
+		settings := protocol.RoomSettings(*patch.Settings)
+		protoPatch.Settings = &settings
+	}
+	if patch.Zone != nil {
+		zone := protocol.SafeZone(*patch.Zone)
+		protoPatch.Zone = &zone
+	}
+	if patch.Bases != nil {
+		bases := make([]protocol.TeamBase, len(patch.Bases))
+		for i, base := range patch.Bases {
+			bases[i] = protocol.TeamBase(base)
+		}
+		protoPatch.Bases = bases
+	}
+	protoPatch.FishCarrier = patch.FishCarrier
+	if patch.Hills != nil {
+		hills := make([]protocol.HillZone, len(patch.Hills))
+		for i, hill := range patch.Hills {
+			hills[i] = protocol.HillZone(hill)
+		}
+		protoPatch.Hills = hills
+	}
+	if patch.Pings != nil {
+		pings := make([]protocol.SoundPing, len(patch.Pings))
+		for i, ping := range patch.Pings {
+			pings[i] = protocol.SoundPing(ping)
+		}
+		protoPatch.Pings = pings
+	}
+	if patch.Hazards != nil {
+		hazards := make([]protocol.HazardCell, len(patch.Hazards))
+		for i, hazard := range patch.Hazards {
+			hazards[i] = protocol.HazardCell(hazard)
 		}
+		protoPatch.Hazards = hazards
 	}
 	if patch.HidePhase != nil {
 		protoPatch.HidePhase = stringPtr(*patch.HidePhase)
@@ -266,6 +370,7 @@ func (r *room) snapshotLocked() gameState {
 	players := make([]*playerState, 0, len(r.players))
 	for _, p := range r.players {
 		cp := *p
+		cp.Effects = append([]statusEffect(nil), p.Effects...)
 		players = append(players, &cp)
 	}
 	stateCopy.Players = players
@@ -273,11 +378,21 @@ func (r *room) snapshotLocked() gameState {
 	stateCopy.Mines = cloneMines(r.state.Mines)
 	stateCopy.PowerUps = clonePowerUps(r.state.PowerUps)
 	stateCopy.Shots = cloneShots(r.state.Shots)
+	stateCopy.Bases = append([]teamBase(nil), r.state.Bases...)
+	stateCopy.Hills = append([]hillZone(nil), r.state.Hills...)
+	stateCopy.Bombs = append([]bomb(nil), r.state.Bombs...)
+	stateCopy.Pings = append([]soundPing(nil), r.state.Pings...)
+	stateCopy.Hazards = append([]hazardCell(nil), r.state.Hazards...)
+	stateCopy.SeekerIDs = append([]string(nil), r.state.SeekerIDs...)
+	if r.state.Zone != nil {
+		zone := *r.state.Zone
+		stateCopy.Zone = &zone
+	}
 	return stateCopy
 }
 
 func (p playerPatch) isEmpty() bool {
-	return p.Name == nil && p.Ready == nil && p.Alive == nil && p.X == nil && p.Y == nil && p.Size == nil && p.Facing == nil && p.Moving == nil && p.WalkCycle == nil && p.StepAccum == nil && p.Score == nil && p.Health == nil && p.Weapon == nil && len(p.Appearance) == 0 && p.Disguise == nil
+	return p.Name == nil && p.Ready == nil && p.Alive == nil && p.X == nil && p.Y == nil && p.Size == nil && p.Facing == nil && p.Moving == nil && p.WalkCycle == nil && p.StepAccum == nil && p.Score == nil && p.Team == nil && p.Health == nil && p.Armor == nil && p.Weapon == nil && p.Ammo == nil && p.Cooldown == nil && p.Charges == nil && p.AbilityCD == nil && len(p.Appearance) == 0 && p.Disguise == nil && p.Effects == nil
 }
 
 func buildPlayerPatch(previous, current *playerState) *playerPatch {
@@ -318,12 +433,30 @@ func buildPlayerPatch(previous, current *playerState) *playerPatch {
 	if previous == nil || previous.Score != current.Score {
 		patch.Score = intPtr(current.Score)
 	}
+	if previous == nil || previous.Team != current.Team {
+		patch.Team = intPtr(current.Team)
+	}
 	if previous == nil || previous.Health != current.Health {
 		patch.Health = intPtr(current.Health)
 	}
+	if previous == nil || previous.Armor != current.Armor {
+		patch.Armor = intPtr(current.Armor)
+	}
 	if previous == nil || previous.Weapon != current.Weapon {
 		patch.Weapon = stringPtr(current.Weapon)
 	}
+	if previous == nil || previous.Ammo != current.Ammo {
+		patch.Ammo = intPtr(current.Ammo)
+	}
+	if previous == nil || floatChanged(previous.Cooldown, current.Cooldown) {
+		patch.Cooldown = floatPtr(current.Cooldown)
+	}
+	if previous == nil || previous.Charges != current.Charges {
+		patch.Charges = intPtr(current.Charges)
+	}
+	if previous == nil || floatChanged(previous.AbilityCD, current.AbilityCD) {
+		patch.AbilityCD = floatPtr(current.AbilityCD)
+	}
 	if !appearanceEqual(previous, current) {
 		patch.Appearance = current.Appearance
 	}
@@ -331,6 +464,10 @@ func buildPlayerPatch(previous, current *playerState) *playerPatch {
 		disguise := current.Disguise
 		patch.Disguise = &disguise
 	}
+	if previous == nil || !effectsEqual(previous.Effects, current.Effects) {
+		effects := append([]statusEffect{}, current.Effects...)
+		patch.Effects = &effects
+	}
 	if patch.isEmpty() {
 		return nil
 	}
@@ -338,7 +475,7 @@ func buildPlayerPatch(previous, current *playerState) *playerPatch {
 }
 
 func (p *statePatch) isEmpty() bool {
-	return p == nil || (p.Mode == nil && p.Phase == nil && p.Countdown == nil && p.Remaining == nil && p.HidePhase == nil && p.ShootPhase == nil && p.Message == nil && p.SeekerID == nil && p.BombHolder == nil && p.BombTimer == nil && p.WinnerID == nil && p.Status == nil && p.Fish == nil && p.PowerUp == nil && p.PowerUps == nil && p.Walls == nil && p.Mines == nil && len(p.Players) == 0 && len(p.RemovedPlayers) == 0 && p.Golden == nil && len(p.Shots) == 0)
+	return p == nil || (p.Mode == nil && p.Phase == nil && p.Countdown == nil && p.Remaining == nil && p.HidePhase == nil && p.ShootPhase == nil && p.Message == nil && p.SeekerIDs == nil && p.Bombs == nil && p.WinnerID == nil && p.WinnerTeam == nil && p.Zone == nil && p.Bases == nil && p.FishCarrier == nil && p.Hills == nil && p.Pings == nil && p.Hazards == nil && p.Fish == nil && p.PowerUp == nil && p.PowerUps == nil && p.Walls == nil && p.Mines == nil && len(p.Players) == 0 && len(p.RemovedPlayers) == 0 && p.Golden == nil && p.HostID == nil && p.Settings == nil && len(p.Shots) == 0)
 }
 
 func buildStatePatch(previous, current gameState) *statePatch {
@@ -365,26 +502,62 @@ func buildStatePatch(previous, current gameState) *statePatch {
 	if previous.Message != current.Message {
 		patch.Message = stringPtr(current.Message)
 	}
-	if previous.SeekerID != current.SeekerID {
-		patch.SeekerID = stringPtr(current.SeekerID)
+	if !stringsEqual(previous.SeekerIDs, current.SeekerIDs) {
+		patch.SeekerIDs = append([]string{}, current.SeekerIDs...)
 	}
-	if previous.BombHolder != current.BombHolder {
-		patch.BombHolder = stringPtr(current.BombHolder)
-	}
-	if floatChanged(previous.BombTimer, current.BombTimer) {
-		patch.BombTimer = floatPtr(current.BombTimer)
+	if !bombsEqual(previous.Bombs, current.Bombs) {
+		patch.Bombs = append([]bomb{}, current.Bombs...)
 	}
 	if previous.WinnerID != current.WinnerID {
 		patch.WinnerID = stringPtr(current.WinnerID)
 	}
+	if previous.WinnerTeam != current.WinnerTeam {
+		patch.WinnerTeam = intPtr(current.WinnerTeam)
+	}
 	if previous.Golden != current.Golden {
 		patch.Golden = boolPtr(current.Golden)
 	}
-	if !statusEqual(previous.Status, current.Status) {
-		if current.Status == nil {
-			patch.Status = &statusEffect{}
+	if previous.HostID != current.HostID {
+		patch.HostID = stringPtr(current.HostID)
+	}
+	if previous.Settings != current.Settings {
+		settingsCopy := current.Settings
+		patch.Settings = &settingsCopy
+	}
+
+	if previous.FishCarrier != current.FishCarrier {
+		patch.FishCarrier = stringPtr(current.FishCarrier)
+	}
+	if !basesEqual(previous.Bases, current.Bases) {
+		if len(current.Bases) == 0 {
+			patch.Bases = []teamBase{}
+		} else {
+			patch.Bases = append([]teamBase{}, current.Bases...)
+		}
+	}
+	if !hillsEqual(previous.Hills, current.Hills) {
+		if len(current.Hills) == 0 {
+			patch.Hills = []hillZone{}
 		} else {
-			patch.Status = current.Status
+			patch.Hills = append([]hillZone{}, current.Hills...)
+		}
+	}
+	if !pingsEqual(previous.Pings, current.Pings) {
+		if len(current.Pings) == 0 {
+			patch.Pings = []soundPing{}
+		} else {
+			patch.Pings = append([]soundPing{}, current.Pings...)
+		}
+	}
+	if !hazardsEqual(previous.Hazards, current.Hazards) {
+		patch.Hazards = append([]hazardCell{}, current.Hazards...)
+	}
+	if !zoneEqual(previous.Zone, current.Zone) {
+		if current.Zone == nil {
+			patch.Zone = &safeZone{}
+		} else {
+			zoneCopy := *current.Zone
+			patch.Zone = &zoneCopy
 		}
 	}
 	if !fishEqual(previous.Fish, current.Fish) {
@@ -457,7 +630,8 @@ type room struct {
 	name               string
 	players            map[string]*playerState
 	inputs             map[string]vector
-	connections        map[*websocket.Conn]string
+	aims               map[string]vector
+	connections        map[*clientConn]string
 	disconnectTimers   map[string]*time.Timer
 	state              gameState
 	lastBroadcastState *gameState
@@ -466,32 +640,66 @@ type room struct {
 	cancel             chan struct{}
 	tickIndex          uint32
 	bombSlowTimers     map[string]float64
-	lastBombPassFrom   string
-	lastBombPassTo     string
-	lastBombPassAt     time.Time
 	bombPowerUpTimer   float64
 	shootRequests      map[string]bool
 	shootingUnlocked   bool
+	eliminations       []string
+	roundStats         map[string]*playerStats
+	tracker            *achievementTracker
+	goldenChainTimer   float64
+	fishIdleTimer      float64
+	pickupCooldowns    map[string]float64
+	hillPoints         map[string]float64
+	hillTimer          float64
+	knockback          map[string]vector
+	shooterSupplyTimer float64
+	zoneShrinkRate     float64
+	zoneDamage         map[string]float64
+	probedProps        map[string]map[int]bool
+	hauntImmunity      map[string]float64
+	wallSegments       []wallSegment
+	wallObjective      gridCell
+	wallSlide          *wallSlide
+	arenaEventTimer    float64
+	mapRounds          map[string]string
 }
 
 type server struct {
 	cats           map[string]catProfile
 	scores         []scoreEntry
+	ratings        map[string]map[string]playerRating
+	stats          map[string]*playerStats
+	achievements   map[string]map[string]time.Time
 	rooms          map[string]*room
 	mu             sync.Mutex
 	upgrader       websocket.Upgrader
 	protocolBinary bool
+	matchmaker     *matchmaker
+	maps           map[string]*arenaMap
+	communityMaps  map[string]*communityMap
+	mapsMu         sync.RWMutex
 }
 
 func newServer() *server {
 	binaryProtocol := parseBoolEnv("BINARY_PROTOCOL_ENABLED")
 	srv := &server{
 		cats:           make(map[string]catProfile),
+		ratings:        make(map[string]map[string]playerRating),
+		stats:          make(map[string]*playerStats),
+		achievements:   make(map[string]map[string]time.Time),
 		rooms:          make(map[string]*room),
+		communityMaps:  make(map[string]*communityMap),
 		upgrader:       websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
 		protocolBinary: binaryProtocol,
 	}
+	srv.matchmaker = newMatchmaker(srv)
 	srv.loadFromDisk()
+	mapsDir := os.Getenv("MAPS_DIR")
+	if mapsDir == "" {
+		mapsDir = mapsDirName
+	}
+	srv.maps = loadArenaMaps(mapsDir)
+	log.Printf("Loaded %d arena maps from %s", len(srv.maps), mapsDir)
 	return srv
 }
 
@@ -505,44 +713,37 @@ func parseBoolEnv(key string) bool {
 }
 
 func normalizeMode(mode string) string {
-	switch mode {
-	case "bomb-pass":
+	if _, ok := lookupMode(mode); ok {
 		return mode
-	case "hide-and-seek":
-		return mode
-	case "shooters":
-		return mode
-	default:
-		return "classic"
 	}
+	return "classic"
 }
 
-func (s *server) getOrCreateRoom(name, mode string) *room {
+func (s *server) getOrCreateRoom(name, mode string, settings *roomSettings) *room {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if existing, ok := s.rooms[name]; ok {
 		return existing
 	}
 	normalizedMode := normalizeMode(mode)
-	world := worldSize
-	if normalizedMode == "bomb-pass" {
-		world = worldSize * bombWorldScale
-	} else if normalizedMode == "hide-and-seek" {
-		world = worldSize * hideSeekWorldScale
-	} else if normalizedMode == "shooters" {
-		world = worldSize * shooterWorldScale
+	roomRules := defaultRoomSettings(normalizedMode)
+	if settings != nil {
+		roomRules = *settings
 	}
+	world := worldSize * roomRules.WorldScale
 	r := &room{
 		name:             name,
 		players:          make(map[string]*playerState),
 		inputs:           make(map[string]vector),
-		connections:      make(map[*websocket.Conn]string),
+		aims:             make(map[string]vector),
+		connections:      make(map[*clientConn]string),
 		disconnectTimers: make(map[string]*time.Timer),
 		cancel:           make(chan struct{}),
 		server:           s,
 		bombSlowTimers:   make(map[string]float64),
 		bombPowerUpTimer: bombPowerUpInterval,
 		shootRequests:    make(map[string]bool),
+		roundStats:       make(map[string]*playerStats),
 	}
 	r.state = gameState{
 		RoomName:   name,
@@ -551,10 +752,11 @@ func (s *server) getOrCreateRoom(name, mode string) *room {
 		Fish:       fishState{X: world / 2, Y: world / 2, Size: fishSize, Alive: false, Type: "normal", Direction: 1},
 		PowerUp:    powerUpState{Size: powerUpSize},
 		PowerUps:   nil,
-		Remaining:  roundDuration.Seconds(),
+		Remaining:  roomRules.RoundDuration,
 		HidePhase:  "",
 		ShootPhase: "",
 		Message:    "Ожидаем игроков",
+		Settings:   roomRules,
 		ServerTime: time.Now().UnixMilli(),
 	}
 	s.rooms[name] = r
@@ -562,11 +764,11 @@ func (s *server) getOrCreateRoom(name, mode string) *room {
 	return r
 }
 
-func (s *server) removeConnection(conn *websocket.Conn) {
+func (s *server) removeConnection(client *clientConn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, r := range s.rooms {
-		r.dropConnection(conn)
+		r.dropConnection(client)
 	}
 }
 
@@ -612,8 +814,12 @@ func (s *server) loadFromDisk() {
 	}
 
 	var payload struct {
-		Cats   map[string]catProfile `json:"cats"`
-		Scores []scoreEntry          `json:"scores"`
+		Cats         map[string]catProfile              `json:"cats"`
+		Scores       []scoreEntry                       `json:"scores"`
+		Ratings      map[string]map[string]playerRating `json:"ratings"`
+		Stats        map[string]*playerStats            `json:"stats"`
+		Achievements map[string]map[string]time.Time    `json:"achievements"`
+		Maps         map[string]*communityMap           `json:"maps"`
 	}
 	if err := json.Unmarshal(data, &payload); err != nil {
 		log.Printf("failed to decode data file: %v", err)
@@ -626,16 +832,41 @@ func (s *server) loadFromDisk() {
 	if payload.Scores != nil {
 		s.scores = payload.Scores
 	}
+	if payload.Ratings != nil {
+		s.ratings = payload.Ratings
+	}
+	for id, stats := range payload.Stats {
+		if stats == nil {
+			continue
+		}
+		merged := newPlayerStats()
+		merged.add(stats)
+		s.stats[id] = merged
+	}
+	if payload.Achievements != nil {
+		s.achievements = payload.Achievements
+	}
+	s.communityMaps = loadCommunityMaps(payload.Maps)
 }
 
 func (s *server) persistLocked() {
 	payload := struct {
-		Cats   map[string]catProfile `json:"cats"`
-		Scores []scoreEntry          `json:"scores"`
+		Cats         map[string]catProfile              `json:"cats"`
+		Scores       []scoreEntry                       `json:"scores"`
+		Ratings      map[string]map[string]playerRating `json:"ratings"`
+		Stats        map[string]*playerStats            `json:"stats"`
+		Achievements map[string]map[string]time.Time    `json:"achievements"`
+		Maps         map[string]*communityMap           `json:"maps"`
 	}{
-		Cats:   s.cats,
-		Scores: s.scores,
+		Cats:         s.cats,
+		Scores:       s.scores,
+		Ratings:      s.ratings,
+		Stats:        s.stats,
+		Achievements: s.achievements,
 	}
+	s.mapsMu.RLock()
+	defer s.mapsMu.RUnlock()
+	payload.Maps = s.communityMaps
 
 	data, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
@@ -659,17 +890,61 @@ func (s *server) topScores(limit int) []scoreEntry {
 	return out
 }
 
-func (s *server) listRooms() []map[string]any {
+// roomListing is a copy of what the lobby list needs from a room, taken
+// under the room lock.
+type roomListing struct {
+	name  string
+	mode  string
+	phase string
+	ids   []string
+}
+
+func (r *room) listing() roomListing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.players))
+	for id := range r.players {
+		ids = append(ids, id)
+	}
+	return roomListing{name: r.name, mode: r.state.Mode, phase: r.state.Phase, ids: ids}
+}
+
+func (s *server) listRooms(playerID string) []map[string]any {
+	// Room locks are never taken under the server lock: rooms call into
+	// the server while holding their own.
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	rooms := make([]map[string]any, 0, len(s.rooms))
+	all := make([]*room, 0, len(s.rooms))
 	for _, r := range s.rooms {
-		rooms = append(rooms, map[string]any{
-			"roomName":    r.name,
-			"mode":        r.state.Mode,
-			"phase":       r.state.Phase,
-			"playerCount": len(r.players),
-			"updatedAt":   time.Now().UnixMilli(),
+		all = append(all, r)
+	}
+	s.mu.Unlock()
+	listings := make([]roomListing, 0, len(all))
+	for _, r := range all {
+		listings = append(listings, r.listing())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rooms := make([]map[string]any, 0, len(listings))
+	for _, l := range listings {
+		average := s.averageRatingLocked(l.ids, l.mode)
+		entry := map[string]any{
+			"roomName":      l.name,
+			"mode":          l.mode,
+			"phase":         l.phase,
+			"playerCount":   len(l.ids),
+			"averageRating": roundFloat(average, 1),
+			"updatedAt":     time.Now().UnixMilli(),
+		}
+		if playerID != "" {
+			entry["ratingGap"] = roundFloat(math.Abs(average-s.ratingLocked(playerID, l.mode).Rating), 1)
+		}
+		rooms = append(rooms, entry)
+	}
+	if playerID != "" {
+		// suggest the most balanced rooms first
+		sort.SliceStable(rooms, func(i, j int) bool {
+			return rooms[i]["ratingGap"].(float64) < rooms[j]["ratingGap"].(float64)
 		})
 	}
 	return rooms
@@ -725,7 +1000,7 @@ func (s *server) handleScores(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) handleRooms(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, map[string]any{"rooms": s.listRooms()})
+	writeJSON(w, map[string]any{"rooms": s.listRooms(r.URL.Query().Get("playerId"))})
 }
 
 func (s *server) handleWS(w http.ResponseWriter, r *http.Request) {
@@ -743,7 +1018,20 @@ func (s *server) handleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	normalizedMode := normalizeMode(mode)
-	rInstance := s.getOrCreateRoom(roomName, normalizedMode)
+	var settings *roomSettings
+	if raw := r.URL.Query().Get("settings"); raw != "" {
+		parsed, err := parseRoomSettings(defaultRoomSettings(normalizedMode), []byte(raw))
+		if err == nil {
+			err = s.checkMapSettings(normalizedMode, &parsed)
+		}
+		if err != nil {
+			conn.WriteJSON(wsMessage{Type: "error", Error: "Некорректные настройки комнаты: " + err.Error()})
+			conn.Close()
+			return
+		}
+		settings = &parsed
+	}
+	rInstance := s.getOrCreateRoom(roomName, normalizedMode, settings)
 	if rInstance.state.Mode != normalizedMode {
 		conn.WriteJSON(wsMessage{Type: "error", Error: "Эта комната создана в другом режиме."})
 		conn.Close()
@@ -752,20 +1040,46 @@ func (s *server) handleWS(w http.ResponseWriter, r *http.Request) {
 	rInstance.handleConnection(conn, playerID, playerName)
 }
 
+// clientConn serialises writes to a player's websocket. gorilla/websocket
+// allows a single writer at a time, while broadcasts, chat, errors and
+// achievement notices are sent from different goroutines.
+type clientConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *clientConn) send(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// playerConnsLocked lists the connections of one player.
+func (r *room) playerConnsLocked(playerID string) []*clientConn {
+	var conns []*clientConn
+	for client, id := range r.connections {
+		if id == playerID {
+			conns = append(conns, client)
+		}
+	}
+	return conns
+}
+
 func (r *room) handleConnection(conn *websocket.Conn, playerID, playerName string) {
+	client := &clientConn{conn: conn}
 	r.mu.Lock()
-	r.connections[conn] = playerID
+	r.connections[client] = playerID
 	_ = r.ensurePlayer(playerID, playerName)
 	r.cancelDisconnectTimerLocked(playerID)
 	r.mu.Unlock()
 
-	r.sendProtocolInfo(conn)
-	r.sendFullState(conn)
+	r.sendProtocolInfo(client)
+	r.sendFullState(client)
 
 	go func() {
 		defer func() {
 			conn.Close()
-			r.dropConnection(conn)
+			r.dropConnection(client)
 		}()
 		for {
 			messageType, data, err := conn.ReadMessage()
@@ -776,9 +1090,10 @@ func (r *room) handleConnection(conn *websocket.Conn, playerID, playerName strin
 				if !r.server.binaryProtocolEnabled() {
 					continue
 				}
-				if pid, vec, shoot := decodeInputBuffer(data); pid != nil && vec != nil {
+				if pid, vec, shoot, aim := decodeInputBuffer(data); pid != nil && vec != nil {
 					r.mu.Lock()
 					r.inputs[*pid] = *vec
+					r.setAimLocked(*pid, aim)
 					if shoot != nil && *shoot {
 						r.shootRequests[*pid] = true
 					}
@@ -805,6 +1120,7 @@ func (r *room) handleClientMessage(playerID string, msg wsMessage) {
 		if msg.Vector != nil {
 			r.mu.Lock()
 			r.inputs[playerID] = *msg.Vector
+			r.setAimLocked(playerID, msg.Aim)
 			if msg.Shoot != nil && *msg.Shoot {
 				r.shootRequests[playerID] = true
 			}
@@ -818,6 +1134,44 @@ func (r *room) handleClientMessage(playerID string, msg wsMessage) {
 		if msg.Appearance != nil {
 			r.updateAppearance(playerID, msg.Appearance)
 		}
+	case "settings":
+		if len(msg.Settings) > 0 {
+			r.updateSettings(playerID, msg.Settings)
+		}
+	case "team":
+		if msg.Team != nil {
+			r.setTeam(playerID, *msg.Team)
+		}
+	case "disguise":
+		r.redisguise(playerID)
+	case "taunt":
+		r.taunt(playerID)
+	case "haunt":
+		r.haunt(playerID)
+	case "rateMap":
+		if msg.Stars != nil {
+			r.rateMap(playerID, *msg.Stars)
+		}
+	}
+}
+
+func (r *room) updateSettings(playerID string, raw json.RawMessage) {
+	r.mu.Lock()
+	settings, err := parseRoomSettings(r.state.Settings, raw)
+	if err == nil {
+		err = r.applySettingsLocked(playerID, settings)
+	}
+	var conns []*clientConn
+	if err != nil {
+		conns = r.playerConnsLocked(playerID)
+	}
+	r.mu.Unlock()
+	if err == nil {
+		return
+	}
+	data, _ := json.Marshal(wsMessage{Type: "error", Error: "Не удалось изменить настройки: " + err.Error()})
+	for _, client := range conns {
+		client.send(websocket.TextMessage, data)
 	}
 }
 
@@ -842,12 +1196,12 @@ func (r *room) updateAppearance(playerID string, appearance catAppearance) {
 	}
 }
 
-func (r *room) dropConnection(conn *websocket.Conn) {
+func (r *room) dropConnection(client *clientConn) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	id, ok := r.connections[conn]
-	delete(r.connections, conn)
-	conn.Close()
+	id, ok := r.connections[client]
+	delete(r.connections, client)
+	client.conn.Close()
 	if ok {
 		r.inputs[id] = vector{}
 		r.schedulePlayerRemovalLocked(id)
@@ -870,7 +1224,9 @@ func (r *room) schedulePlayerRemovalLocked(playerID string) {
 		}
 		delete(r.disconnectTimers, playerID)
 		delete(r.inputs, playerID)
+		delete(r.aims, playerID)
 		delete(r.players, playerID)
+		r.pickHostLocked()
 		if len(r.players) == 0 {
 			r.state.Phase = "lobby"
 			r.state.Message = "Ожидаем игроков"
@@ -890,20 +1246,24 @@ func (r *room) cancelDisconnectTimerLocked(playerID string) {
 func (r *room) ensurePlayer(id, name string) *playerState {
 	player, ok := r.players[id]
 	if !ok {
-		world := r.currentWorldSize()
-		player = &playerState{ID: id, Name: fallbackName(name), Size: catSize, X: world / 2, Y: world / 2, Facing: 1}
+		pos := r.spawnPointLocked()
+		player = &playerState{ID: id, Name: fallbackName(name), Size: catSize, X: pos.X, Y: pos.Y, Facing: 1}
+		r.assignTeamLocked(player)
 		r.players[id] = player
 	}
+	if r.state.HostID == "" {
+		r.state.HostID = id
+	}
 	if name != "" {
 		player.Name = name
 	}
 	if player.Health == 0 {
-		player.Health = shooterMaxHealth
+		player.Health = r.state.Settings.ShooterMaxHealth
 	}
 	return player
 }
 
-func (r *room) sendFullState(conn *websocket.Conn) {
+func (r *room) sendFullState(client *clientConn) {
 	r.mu.Lock()
 	stateCopy := r.snapshotLocked()
 	r.mu.Unlock()
@@ -911,12 +1271,12 @@ func (r *room) sendFullState(conn *websocket.Conn) {
 	quantizeStateForSend(&stateCopy)
 	if r.server.binaryProtocolEnabled() {
 		data := protocol.EncodeState(toProtocolGameState(stateCopy))
-		conn.WriteMessage(websocket.BinaryMessage, data)
+		client.send(websocket.BinaryMessage, data)
 		return
 	}
 	msg := wsMessage{Type: "state", State: &stateCopy, Full: true}
 	data, _ := json.Marshal(msg)
-	conn.WriteMessage(websocket.TextMessage, data)
+	client.send(websocket.TextMessage, data)
 }
 
 func (r *room) run() {
@@ -952,57 +1312,8 @@ func (r *room) step() {
 		}
 	case "playing":
 		r.updateStatusEffectLocked()
-		if r.isBombMode() {
-			r.updatePlayersLocked()
-			r.updateBombPowerUpLocked()
-			r.updateBombPassLocked()
-		} else if r.isShooterMode() {
-			r.state.Remaining -= tickRate.Seconds()
-			r.updatePlayersLocked()
-			r.tickShooterPhaseLocked()
-			if r.shootingUnlocked {
-				r.resolveShooterCombatLocked()
-			}
-			r.updateShotsLocked()
-			if r.state.Remaining <= 0 {
-				r.endRoundLocked("Время вышло")
-				return
-			}
-			if r.countAlivePlayersLocked() <= 1 {
-				r.endRoundLocked("Выжил только один котик!")
-				return
-			}
-		} else if r.isHideSeekMode() {
-			r.state.Remaining -= tickRate.Seconds()
-			r.updatePlayersLocked()
-			if r.state.HidePhase == "hiding" {
-				if r.state.Remaining <= 0 {
-					r.startHideSeekSearchPhaseLocked()
-				}
-			} else {
-				r.handleHideSeekCapturesLocked()
-				if r.state.Remaining <= 0 {
-					r.state.WinnerID = r.pickAliveHiderLocked()
-					if r.state.WinnerID == "" {
-						r.state.WinnerID = r.state.SeekerID
-					}
-					r.endRoundLocked("Время на поиск закончилось")
-					return
-				}
-			}
-		} else {
-			r.state.Remaining -= tickRate.Seconds()
-			r.updatePowerUpLocked()
-			r.updatePlayersLocked()
-			if r.countAlivePlayersLocked() == 0 {
-				r.endRoundLocked("Раунд завершён: все коты погибли")
-				return
-			}
-			r.updateFishLocked()
-			if r.state.Remaining <= 0 {
-				r.endRoundLocked("Раунд завершён")
-			}
-		}
+		r.updateArenaEventsLocked()
+		r.mode().Tick(r)
 	default:
 		r.updateLobbyMessageLocked()
 	}
@@ -1011,72 +1322,56 @@ func (r *room) step() {
 func (r *room) beginRoundLocked() {
 	r.state.Phase = "playing"
 	r.state.Countdown = 0
-	r.state.Remaining = roundDuration.Seconds()
+	settings := r.state.Settings
+	r.state.Remaining = settings.RoundDuration
 	r.state.Message = "Раунд начался"
-	r.state.Status = nil
 	r.state.Walls = nil
 	r.state.Mines = nil
+	r.state.Hazards = nil
+	center := gridDimension(r.currentWorldSize()) / 2
+	r.setWallSegmentsLocked(nil, gridCell{Row: center, Col: center})
+	r.arenaEventTimer = settings.ArenaEventInterval
 	r.state.PowerUp = powerUpState{Size: powerUpSize}
 	r.state.Shots = nil
-	r.state.SeekerID = ""
+	r.state.SeekerIDs = nil
 	r.state.HidePhase = ""
 	r.state.WinnerID = ""
+	r.state.WinnerTeam = 0
 	r.state.ShootPhase = ""
+	r.state.Zone = nil
+	r.state.Bases = nil
+	r.state.FishCarrier = ""
+	r.state.Hills = nil
+	r.state.Pings = nil
 	r.bombSlowTimers = make(map[string]float64)
 	r.shootRequests = make(map[string]bool)
-	r.resetBombPassHistoryLocked()
-	if r.isBombMode() {
-		r.state.Fish = fishState{Size: fishSize, Alive: false, Type: "normal", Direction: 1}
-		r.state.PowerUp.Active = false
-		r.state.PowerUps = nil
-		r.state.Mines = nil
-		r.buildArenaWithWallsLocked()
-		r.bombPowerUpTimer = 0
-		r.updateBombPowerUpLocked()
-	} else if r.isShooterMode() {
-		r.state.Fish = fishState{Size: fishSize, Alive: false, Type: "normal", Direction: 1}
-		r.state.PowerUp.Active = false
-		r.state.PowerUps = r.spawnShooterLootLocked()
-		r.state.Mines = nil
-		r.state.Remaining = shooterRoundDuration
-		r.state.Countdown = shooterPrepDuration
-		r.state.ShootPhase = "loot"
-		r.shootingUnlocked = false
-		r.state.Message = "Подготовка: найдите оружие!"
-		r.buildArenaWithWallsLocked()
-	} else if r.isHideSeekMode() {
-		r.state.Fish = fishState{Size: fishSize, Alive: false, Type: "normal", Direction: 1}
-		r.state.PowerUp.Active = false
-		r.state.PowerUps = r.spawnHideAndSeekItemsLocked()
-		r.state.Mines = nil
-		r.state.Remaining = hideSeekHideDuration
-		r.state.HidePhase = "hiding"
-		r.state.SeekerID = r.pickRandomSeekerLocked()
-		seekerName := "случайный котик"
-		if seeker, ok := r.players[r.state.SeekerID]; ok && seeker != nil {
-			seekerName = fallbackName(seeker.Name)
-		}
-		r.state.Message = fmt.Sprintf("Ведущий: %s. У вас минута, чтобы спрятаться!", seekerName)
-		r.buildArenaWithWallsLocked()
-	} else {
-		r.spawnFishLocked()
-	}
+	r.eliminations = nil
+	r.roundStats = make(map[string]*playerStats)
+	r.tracker = newAchievementTracker(r.state.Mode)
+	r.goldenChainTimer = 0
+	r.state.Golden = false
+	r.balanceTeamsLocked()
 	for _, p := range r.players {
 		p.Alive = true
 		p.Score = 0
 		p.Disguise = ""
 		p.Size = catSize
-		p.Health = shooterMaxHealth
+		p.Health = settings.ShooterMaxHealth
+		p.Armor = 0
 		p.Weapon = ""
+		p.Ammo = 0
+		p.Cooldown = 0
+		p.Charges = 0
+		p.AbilityCD = 0
+		p.Effects = nil
 	}
-	r.state.BombHolder = ""
-	r.state.BombTimer = bombTimerDuration
-	if r.isBombMode() {
-		r.assignBombToRandomAliveLocked(true)
-	}
+	r.state.Bombs = nil
+	r.spreadPlayersLocked()
+	r.mode().StartRound(r)
 }
 
 func (r *room) endRoundLocked(reason string) {
+	wasPlaying := r.state.Phase == "playing"
 	r.state.Phase = "ended"
 	r.state.Shots = nil
 	if reason != "" {
@@ -1087,50 +1382,34 @@ func (r *room) endRoundLocked(reason string) {
 	r.state.Fish.Alive = false
 	r.state.Countdown = 0
 	r.state.ShootPhase = ""
+	r.state.Zone = nil
+	r.state.FishCarrier = ""
 	r.shootingUnlocked = false
+	r.state.WinnerTeam = r.winningTeamLocked()
 	r.state.WinnerID = r.bestPlayerIDLocked()
-}
-
-func (r *room) bestPlayerIDLocked() string {
-	if r.isHideSeekMode() {
-		if r.state.WinnerID != "" {
-			return r.state.WinnerID
-		}
-		if r.state.HidePhase == "seeking" {
-			hider := r.pickAliveHiderLocked()
-			if hider != "" {
-				return hider
-			}
-			if r.state.SeekerID != "" {
-				return r.state.SeekerID
-			}
+	if wasPlaying {
+		if winner, ok := r.players[r.state.WinnerID]; ok {
+			r.emitEventLocked(gameEvent{Type: "roundWon", PlayerID: winner.ID, Detail: winner.Disguise})
 		}
+		r.flushRoundStatsLocked(r.finalRankingLocked())
+		r.recordMapRoundLocked()
 	}
+}
 
-	var best *playerState
-	if r.isBombMode() {
-		for _, p := range r.players {
-			if p.Alive {
-				return p.ID
-			}
-		}
-		return ""
-	}
-	if r.isShooterMode() {
-		alive := r.alivePlayersLocked()
-		if len(alive) == 1 {
-			return alive[0].ID
-		}
-	}
-	for _, p := range r.players {
-		if best == nil || p.Score > best.Score {
-			best = p
+func (r *room) recordEliminationLocked(p *playerState) {
+	p.Alive = false
+	p.Moving = false
+	r.eliminations = append(r.eliminations, p.ID)
+}
+
+func (r *room) bestPlayerIDLocked() string {
+	if r.teamsEnabled() {
+		if team := r.winningTeamLocked(); team != 0 {
+			return r.bestTeamPlayerLocked(team)
 		}
-	}
-	if best == nil {
 		return ""
 	}
-	return best.ID
+	return r.mode().Winner(r)
 }
 
 func (r *room) updatePlayersLocked() {
@@ -1142,11 +1421,19 @@ func (r *room) updatePlayersLocked() {
 		speedMultiplier := r.getSpeedMultiplierLocked(p.ID)
 		input := r.inputs[id]
 		speed := catSpeed * tickRate.Seconds() * speedMultiplier
-		if r.isBombMode() {
-			speed *= r.getBombSpeedMultiplierLocked(id)
+		speed *= r.getBombSpeedMultiplierLocked(id)
+		speed *= r.captureSpeedMultiplierLocked(id)
+		if hasEffect(p, "invert") {
+			input = vector{X: -input.X, Y: -input.Y}
+		}
+		kb := r.knockbackStepLocked(p)
+		dx, dy := input.X*speed+kb.X, input.Y*speed+kb.Y
+		if hasEffect(p, "ghost") {
+			p.X += dx
+			p.Y += dy
+		} else {
+			p.X, p.Y, _ = moveCircle(p.X, p.Y, p.Size/2, dx, dy, r.state.Walls)
 		}
-		p.X += input.X * speed
-		p.Y += input.Y * speed
 		p.Moving = math.Abs(input.X) > 0.01 || math.Abs(input.Y) > 0.01
 		if p.Moving {
 			p.Facing = 1
@@ -1156,36 +1443,27 @@ func (r *room) updatePlayersLocked() {
 			p.StepAccum += tickRate.Seconds() * 4
 			p.WalkCycle = math.Mod(p.StepAccum, 1)
 		}
-		resolveEntityWallCollisions(p, r.state.Walls)
+		// Walls can still move onto a cat, e.g. sliding arena walls.
+		if !hasEffect(p, "ghost") {
+			resolveEntityWallCollisions(p, r.state.Walls)
+		}
 		p.X = clampFloat(p.X, p.Size/2, world-p.Size/2)
 		p.Y = clampFloat(p.Y, p.Size/2, world-p.Size/2)
 
-		if r.isBombMode() {
-			r.collectBombPowerUpsLocked(p)
-		} else if r.isHideSeekMode() {
-			r.handleHideSeekDisguiseLocked(p)
-		} else if r.isShooterMode() {
-			r.collectShooterLootLocked(p)
-		} else if r.state.PowerUp.Active {
-			dist := math.Hypot(p.X-r.state.PowerUp.X, p.Y-r.state.PowerUp.Y)
-			if dist < (p.Size+r.state.PowerUp.Size)/2 {
-				r.state.PowerUp.Active = false
-				r.state.PowerUp.Remaining = 0
-				r.applyRandomStatusEffectLocked("")
-			}
-		}
-		for _, m := range r.state.Mines {
+		r.mode().Pickup(r, p)
+		for i, m := range r.state.Mines {
 			if math.Hypot(p.X-m.X, p.Y-m.Y) < (p.Size+m.Size)/2 {
-				p.Alive = false
-				p.Moving = false
+				if removeEffect(p, "shield") {
+					r.state.Mines = append(r.state.Mines[:i:i], r.state.Mines[i+1:]...)
+				} else {
+					r.recordEliminationLocked(p)
+				}
 				break
 			}
 		}
 	}
 
-	if r.isBombMode() {
-		r.resolvePlayerCollisionsLocked()
-	}
+	r.mode().Collide(r)
 }
 
 func (r *room) countAlivePlayersLocked() int {
@@ -1221,37 +1499,19 @@ func (r *room) isShooterMode() bool {
 }
 
 func (r *room) currentWorldSize() float64 {
-	if r.isBombMode() {
-		return worldSize * bombWorldScale
-	}
-	if r.isHideSeekMode() {
-		return worldSize * hideSeekWorldScale
-	}
-	if r.isShooterMode() {
-		return worldSize * shooterWorldScale
-	}
-	return worldSize
+	return worldSize * r.state.Settings.WorldScale
 }
 
 func (r *room) wallThicknessRate() float64 {
-	if r.isBombMode() || r.isHideSeekMode() || r.isShooterMode() {
-		return bombWallThicknessRate
-	}
-	return wallThicknessRate
+	return r.mode().WallParams().ThicknessRate
 }
 
 func (r *room) maxWallTotalLen() int {
-	if r.isBombMode() || r.isHideSeekMode() || r.isShooterMode() {
-		return bombMaxWallTotalLen
-	}
-	return maxWallTotalLen
+	return r.mode().WallParams().MaxTotalLen
 }
 
 func (r *room) maxSegments() int {
-	if r.isBombMode() || r.isHideSeekMode() || r.isShooterMode() {
-		return bombMaxSegments
-	}
-	return maxSegments
+	return r.mode().WallParams().MaxSegments
 }
 
 func (r *room) applyBombSlowdownLocked(playerID string) {
@@ -1283,12 +1543,6 @@ func (r *room) getBombSpeedMultiplierLocked(playerID string) float64 {
 	return 1
 }
 
-func (r *room) resetBombPassHistoryLocked() {
-	r.lastBombPassFrom = ""
-	r.lastBombPassTo = ""
-	r.lastBombPassAt = time.Time{}
-}
-
 func (r *room) resolvePlayerCollisionsLocked() {
 	players := r.alivePlayersLocked()
 	world := r.currentWorldSize()
@@ -1329,55 +1583,9 @@ func (r *room) resolvePlayerCollisionsLocked() {
 	}
 }
 
-func (r *room) assignBombToRandomAliveLocked(resetTimer bool) {
-	alive := r.alivePlayersLocked()
-	if len(alive) == 0 {
-		r.state.BombHolder = ""
-		return
-	}
-	r.resetBombPassHistoryLocked()
-	picked := alive[rand.Intn(len(alive))]
-	r.state.BombHolder = picked.ID
-	if resetTimer {
-		r.state.BombTimer = bombTimerDuration
-	}
-	r.applyBombSlowdownLocked(picked.ID)
-	r.state.Message = fmt.Sprintf("Бомба у %s!", fallbackName(picked.Name))
-}
-
-func (r *room) handleBombTransferLocked() {
-	holder, ok := r.players[r.state.BombHolder]
-	if !ok || holder == nil || !holder.Alive {
-		return
-	}
-	for id, p := range r.players {
-		if id == holder.ID || !p.Alive {
-			continue
-		}
-		dist := math.Hypot(holder.X-p.X, holder.Y-p.Y)
-		if dist <= (holder.Size+p.Size)/2 {
-			recentBackTransfer :=
-				r.lastBombPassFrom != "" &&
-					r.lastBombPassTo != "" &&
-					time.Since(r.lastBombPassAt) < time.Second &&
-					holder.ID == r.lastBombPassTo &&
-					p.ID == r.lastBombPassFrom
-			if recentBackTransfer {
-				continue
-			}
-			r.state.BombHolder = p.ID
-			r.state.BombTimer = math.Max(r.state.BombTimer, 0) + bombTimerBonus
-			r.applyBombSlowdownLocked(p.ID)
-			r.lastBombPassFrom = holder.ID
-			r.lastBombPassTo = p.ID
-			r.lastBombPassAt = time.Now()
-			r.state.Message = fmt.Sprintf("%s передал бомбу %s", fallbackName(holder.Name), fallbackName(p.Name))
-			return
-		}
-	}
-}
-
-func (r *room) pickRandomSeekerLocked() string {
+// pickSeekersLocked picks one seeker per hidersPerSeeker players, always
+// leaving at least one hider.
+func (r *room) pickSeekersLocked() []string {
 	candidates := r.alivePlayersLocked()
 	if len(candidates) == 0 {
 		for _, p := range r.players {
@@ -1385,10 +1593,16 @@ func (r *room) pickRandomSeekerLocked() string {
 		}
 	}
 	if len(candidates) == 0 {
-		return ""
+		return nil
+	}
+	count := clampInt(len(candidates)/hidersPerSeeker, 1, max(len(candidates)-1, 1))
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	seekers := make([]string, 0, count)
+	for _, p := range candidates[:count] {
+		seekers = append(seekers, p.ID)
 	}
-	picked := candidates[rand.Intn(len(candidates))]
-	return picked.ID
+	sort.Strings(seekers)
+	return seekers
 }
 
 func (r *room) spawnHideAndSeekItemsLocked() []powerUpState {
@@ -1397,15 +1611,18 @@ func (r *room) spawnHideAndSeekItemsLocked() []powerUpState {
 		return nil
 	}
 	count := totalPlayers * 3
-	world := r.currentWorldSize()
 	items := make([]powerUpState, 0, count)
-	margin := 20.0
 	disguiseTypes := []string{"memory", "chair", "table", "fish", "duck", "goose", "goldfish", "mine", "alarm"}
+	reach := r.reachableAreaLocked()
 	for i := 0; i < count; i++ {
-		x := rand.Float64()*(world-2*margin) + margin
-		y := rand.Float64()*(world-2*margin) + margin
+		x, y, ok := r.itemPositionLocked(powerUpSize/2, 20, reach)
+		if !ok {
+			continue
+		}
 		disguise := disguiseTypes[rand.Intn(len(disguiseTypes))]
-		items = append(items, powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: 60, Type: disguise})
+		// Props never expire or get picked up: they stay on the map as
+		// decoys next to the hiders that copy them.
+		items = append(items, powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Type: disguise})
 	}
 	return items
 }
@@ -1414,7 +1631,7 @@ func (r *room) handleHideSeekDisguiseLocked(p *playerState) {
 	if !r.isHideSeekMode() || r.state.HidePhase != "hiding" {
 		return
 	}
-	if p == nil || !p.Alive || p.ID == r.state.SeekerID {
+	if p == nil || !p.Alive || r.isSeekerLocked(p.ID) {
 		return
 	}
 	for _, item := range r.state.PowerUps {
@@ -1435,15 +1652,16 @@ func (r *room) spawnShooterLootLocked() []powerUpState {
 	if totalPlayers == 0 {
 		return nil
 	}
-	world := r.currentWorldSize()
 	count := clampInt(totalPlayers*2, 3, 12)
-	margin := 20.0
 	weapons := []string{"blaster", "laser", "pistol", "plasma"}
 	loot := make([]powerUpState, 0, count)
+	reach := r.reachableAreaLocked()
 	for i := 0; i < count; i++ {
-		x := rand.Float64()*(world-2*margin) + margin
-		y := rand.Float64()*(world-2*margin) + margin
-		loot = append(loot, powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: shooterPrepDuration, Type: weapons[rand.Intn(len(weapons))]})
+		x, y, ok := r.itemPositionLocked(powerUpSize/2, 20, reach)
+		if !ok {
+			continue
+		}
+		loot = append(loot, powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: r.state.Settings.ShooterPrepDuration, Type: weapons[rand.Intn(len(weapons))]})
 	}
 	return loot
 }
@@ -1458,13 +1676,24 @@ func (r *room) collectShooterLootLocked(p *playerState) {
 			continue
 		}
 		dist := math.Hypot(p.X-item.X, p.Y-item.Y)
-		if dist <= (p.Size+item.Size)/2 {
-			p.Weapon = item.Type
-			item.Active = false
-			item.Remaining = 0
-			r.state.Message = fmt.Sprintf("%s нашёл оружие: %s", fallbackName(p.Name), item.Type)
-			return
+		if dist > (p.Size+item.Size)/2 {
+			continue
+		}
+		if isShooterSupplyItem(item.Type) {
+			if r.applyShooterSupplyLocked(p, item) {
+				item.Active = false
+				item.Remaining = 0
+				return
+			}
+			continue
 		}
+		p.Weapon = item.Type
+		p.Ammo = lookupWeapon(item.Type).Ammo
+		p.Cooldown = 0
+		item.Active = false
+		item.Remaining = 0
+		r.state.Message = fmt.Sprintf("%s нашёл оружие: %s", fallbackName(p.Name), item.Type)
+		return
 	}
 }
 
@@ -1477,7 +1706,9 @@ func (r *room) tickShooterPhaseLocked() {
 		r.state.Countdown = 0
 		r.shootingUnlocked = true
 		r.state.ShootPhase = "fight"
-		r.state.Message = "Стрельба разрешена!"
+		r.state.Message = "Стрельба разрешена! Зона начинает сужаться"
+		r.shooterSupplyTimer = shooterSupplyInterval
+		r.startSafeZoneLocked()
 	}
 }
 
@@ -1494,74 +1725,160 @@ func (r *room) resolveShooterCombatLocked() {
 		if shooter == nil || !shooter.Alive || shooter.Weapon == "" {
 			continue
 		}
+		if shooter.Cooldown > 0 || shooter.Ammo <= 0 {
+			continue
+		}
+		r.fireWeaponLocked(shooter, lookupWeapon(shooter.Weapon))
+	}
+	r.shootRequests = make(map[string]bool)
+}
 
-		direction := 1.0
-		if shooter.Facing < 0 {
-			direction = -1.0
+func (r *room) setAimLocked(playerID string, aim *vector) {
+	if aim == nil {
+		return
+	}
+	length := math.Hypot(aim.X, aim.Y)
+	if length < 0.01 {
+		return
+	}
+	r.aims[playerID] = vector{X: aim.X / length, Y: aim.Y / length}
+}
+
+// aimDirectionLocked returns the unit vector a player is aiming along,
+// falling back to the facing direction for clients that send no aim.
+func (r *room) aimDirectionLocked(p *playerState) (float64, float64) {
+	if aim, ok := r.aims[p.ID]; ok {
+		return aim.X, aim.Y
+	}
+	if p.Facing < 0 {
+		return -1, 0
+	}
+	return 1, 0
+}
+
+func (r *room) fireWeaponLocked(shooter *playerState, weapon weaponStats) {
+	aimX, aimY := r.aimDirectionLocked(shooter)
+	angle := math.Atan2(aimY, aimX) + (rand.Float64()*2-1)*weapon.Spread
+	dirX, dirY := math.Cos(angle), math.Sin(angle)
+	if dirX < -0.01 {
+		shooter.Facing = -1
+	} else if dirX > 0.01 {
+		shooter.Facing = 1
+	}
+	damage := weapon.damage(r.state.Settings.ShooterDamage)
+
+	reach := weapon.Range
+	shotToX := shooter.X + dirX*reach
+	shotToY := shooter.Y + dirY*reach
+	if hitX, hitY, blocked := r.findWallIntersection(shooter.X, shooter.Y, shotToX, shotToY); blocked {
+		shotToX = hitX
+		shotToY = hitY
+		reach = math.Hypot(hitX-shooter.X, hitY-shooter.Y)
+	}
+
+	type rayHit struct {
+		player *playerState
+		dist   float64
+	}
+	hits := []rayHit{}
+	for _, p := range r.players {
+		if p == nil || !p.Alive || p.ID == shooter.ID || !r.canDamageLocked(shooter, p) {
+			continue
+		}
+		dist, ok := rayCircleIntersection(shooter.X, shooter.Y, dirX, dirY, p.X, p.Y, p.Size/2)
+		if !ok || dist > reach {
+			continue
 		}
+		hits = append(hits, rayHit{player: p, dist: dist})
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].dist < hits[j].dist })
+	if !weapon.Pierce && len(hits) > 1 {
+		hits = hits[:1]
+	}
 
-		shotToX := shooter.X + direction*shooterShotRange
-		shotToY := shooter.Y
+	shooter.Ammo--
+	shooter.Cooldown = weapon.Cooldown
+	shooterStats := r.roundStatsLocked(shooter.ID)
+	shooterStats.ShotsFired++
+	if len(hits) > 0 {
+		shooterStats.ShotsHit++
+		if !weapon.Pierce {
+			shotToX = shooter.X + dirX*hits[0].dist
+			shotToY = shooter.Y + dirY*hits[0].dist
+		}
+	}
+	targets := make([]*playerState, 0, len(hits))
+	for _, hit := range hits {
+		targets = append(targets, hit.player)
+		r.damagePlayerLocked(shooter, hit.player, damage)
+	}
 
-		var target *playerState
-		bestDist := shooterShotRange + 1
+	if weapon.SplashRadius > 0 {
+		splashDamage := weapon.splashDamage(r.state.Settings.ShooterDamage)
 		for _, p := range r.players {
-			if p == nil || !p.Alive || p.ID == shooter.ID {
-				continue
-			}
-
-			dx := p.X - shooter.X
-			if direction > 0 && dx <= 0 {
+			if p == nil || !p.Alive || p.ID == shooter.ID || containsPlayer(targets, p) || !r.canDamageLocked(shooter, p) {
 				continue
 			}
-			if direction < 0 && dx >= 0 {
-				continue
+			if math.Hypot(p.X-shotToX, p.Y-shotToY) <= weapon.SplashRadius+p.Size/2 {
+				r.damagePlayerLocked(shooter, p, splashDamage)
 			}
+		}
+	}
+	if shooter.Ammo <= 0 {
+		shooter.Weapon = ""
+		shooter.Ammo = 0
+	}
 
-			dist := math.Abs(dx)
-			if dist > shooterShotRange {
-				continue
-			}
-			if math.Abs(p.Y-shooter.Y) > p.Size/2 {
-				continue
-			}
-			if !r.hasLineOfSight(shooter.X, shooter.Y, p.X, shooter.Y) {
-				continue
-			}
+	r.state.Shots = append(r.state.Shots, shotEvent{
+		ShooterID: shooter.ID,
+		FromX:     shooter.X,
+		FromY:     shooter.Y,
+		ToX:       shotToX,
+		ToY:       shotToY,
+		Remaining: shooterShotLifetime,
+	})
+}
 
-			if dist < bestDist {
-				target = p
-				bestDist = dist
-			}
-		}
+func (r *room) damagePlayerLocked(shooter, target *playerState, damage int) {
+	if !target.Alive {
+		return
+	}
+	if target.Armor > 0 {
+		absorbed := min(target.Armor, damage)
+		target.Armor -= absorbed
+		damage -= absorbed
+	}
+	target.Health -= damage
+	if target.Health > 0 {
+		return
+	}
+	target.Health = 0
+	r.recordEliminationLocked(target)
+	r.roundStatsLocked(target.ID).ShooterDeaths++
+	if sameTeam(shooter, target) {
+		r.state.Message = fmt.Sprintf("%s выбил союзника %s", fallbackName(shooter.Name), fallbackName(target.Name))
+		return
+	}
+	r.roundStatsLocked(shooter.ID).ShooterKills++
+	r.emitEventLocked(gameEvent{Type: "playerKilled", PlayerID: shooter.ID, Detail: target.ID})
+	shooter.Score++
+	r.state.Message = fmt.Sprintf("%s выбил %s", fallbackName(shooter.Name), fallbackName(target.Name))
+}
 
-		if target != nil {
-			shotToX = target.X
-			target.Health -= shooterDamage
-			if target.Health <= 0 {
-				target.Health = 0
-				target.Alive = false
-				target.Moving = false
-				shooter.Score++
-				r.state.Message = fmt.Sprintf("%s выбил %s", fallbackName(shooter.Name), fallbackName(target.Name))
-			}
+func (r *room) tickWeaponCooldownsLocked() {
+	for _, p := range r.players {
+		if p.Cooldown > 0 {
+			p.Cooldown = math.Max(p.Cooldown-tickRate.Seconds(), 0)
 		}
+	}
+}
 
-		if hitX, hitY, blocked := r.findWallIntersection(shooter.X, shooter.Y, shotToX, shotToY); blocked {
-			shotToX = hitX
-			shotToY = hitY
+func (r *room) tickAbilityCooldownsLocked() {
+	for _, p := range r.players {
+		if p.AbilityCD > 0 {
+			p.AbilityCD = math.Max(p.AbilityCD-tickRate.Seconds(), 0)
 		}
-
-		r.state.Shots = append(r.state.Shots, shotEvent{
-			ShooterID: shooter.ID,
-			FromX:     shooter.X,
-			FromY:     shooter.Y,
-			ToX:       shotToX,
-			ToY:       shotToY,
-			Remaining: shooterShotLifetime,
-		})
 	}
-	r.shootRequests = make(map[string]bool)
 }
 
 func (r *room) updateShotsLocked() {
@@ -1582,7 +1899,7 @@ func (r *room) updateShotsLocked() {
 func (r *room) countRemainingHidersLocked() int {
 	count := 0
 	for id, player := range r.players {
-		if id == r.state.SeekerID {
+		if r.isSeekerLocked(id) {
 			continue
 		}
 		if player != nil && player.Alive {
@@ -1594,91 +1911,93 @@ func (r *room) countRemainingHidersLocked() int {
 
 func (r *room) startHideSeekSearchPhaseLocked() {
 	r.state.HidePhase = "seeking"
-	r.state.Remaining = hideSeekSeekDuration
-	r.state.Message = "Время вышло! Ведущий начинает поиск: подходите к предметам и касайтесь их, чтобы проверить. На поиски — 3 минуты."
+	r.state.Remaining = r.state.Settings.SeekDuration
+	r.state.Message = fmt.Sprintf("Время вышло! Ведущий начинает поиск: подходите к предметам и касайтесь их, чтобы проверить. На поиски — %s.", formatSecondsRu(r.state.Settings.SeekDuration))
 }
 
 func (r *room) handleHideSeekCapturesLocked() {
 	if !r.isHideSeekMode() || r.state.HidePhase != "seeking" {
 		return
 	}
-	seeker, ok := r.players[r.state.SeekerID]
-	if !ok || seeker == nil || !seeker.Alive {
-		return
-	}
-
-	touchesObject := func(x, y, size float64) bool {
-		return math.Hypot(seeker.X-x, seeker.Y-y) <= (seeker.Size+size)/2
-	}
-	for id, player := range r.players {
-		if id == seeker.ID || player == nil || !player.Alive {
+	// Infected hiders join SeekerIDs while we iterate, so work on a copy.
+	for _, seekerID := range append([]string(nil), r.state.SeekerIDs...) {
+		seeker, ok := r.players[seekerID]
+		if !ok || seeker == nil || !seeker.Alive {
+			continue
+		}
+		r.probeDecoysLocked(seeker)
+		if seeker.AbilityCD > 0 {
 			continue
 		}
-		if touchesObject(player.X, player.Y, player.Size) {
-			player.Alive = false
-			player.Moving = false
-			player.Disguise = ""
-			player.Size = catSize
-			seeker.Score++
-			remaining := r.countRemainingHidersLocked()
-			if remaining == 0 {
-				r.state.WinnerID = seeker.ID
-				r.endRoundLocked("Ведущий нашёл всех!")
+		for _, id := range sortedPlayerIDs(r.players) {
+			player := r.players[id]
+			if r.isSeekerLocked(id) || !player.Alive {
+				continue
+			}
+			if math.Hypot(seeker.X-player.X, seeker.Y-player.Y) > (seeker.Size+player.Size)/2 {
+				continue
+			}
+			r.catchHiderLocked(seeker, player)
+			if r.state.Phase != "playing" {
 				return
 			}
-			r.state.Message = fmt.Sprintf("%s нашёл игрока! Осталось спрятанных: %d", fallbackName(seeker.Name), remaining)
 		}
 	}
 }
 
-func (r *room) pickAliveHiderLocked() string {
-	for id, player := range r.players {
-		if id == r.state.SeekerID {
-			continue
-		}
-		if player != nil && player.Alive {
-			return id
-		}
+// catchHiderLocked eliminates a found hider, or in the infection variant
+// turns them into another seeker.
+func (r *room) catchHiderLocked(seeker, player *playerState) {
+	player.Disguise = ""
+	player.Size = catSize
+	seeker.Score++
+	r.roundStatsLocked(seeker.ID).HidersFound++
+	r.emitEventLocked(gameEvent{Type: "hiderFound", PlayerID: seeker.ID, Detail: player.ID})
+	if r.state.Settings.Infection {
+		player.Charges = 0
+		player.AbilityCD = 0
+		r.eliminations = append(r.eliminations, player.ID)
+		r.state.SeekerIDs = append(r.state.SeekerIDs, player.ID)
+		sort.Strings(r.state.SeekerIDs)
+	} else {
+		r.recordEliminationLocked(player)
 	}
-	return ""
-}
-
-func (r *room) updateBombPassLocked() {
-	if r.countAlivePlayersLocked() <= 1 {
-		r.endRoundLocked("Раунд завершён")
+	remaining := r.countRemainingHidersLocked()
+	if remaining == 0 {
+		r.state.WinnerID = r.bestSeekerLocked()
+		r.endRoundLocked("Ведущие нашли всех!")
 		return
 	}
-	r.tickBombSlowdownsLocked()
-	holder, exists := r.players[r.state.BombHolder]
-	if r.state.BombHolder == "" || !exists || holder == nil || !holder.Alive {
-		r.assignBombToRandomAliveLocked(true)
-	}
-	r.handleBombTransferLocked()
-	r.state.BombTimer -= tickRate.Seconds()
-	if r.state.BombTimer < 0 {
-		r.state.BombTimer = 0
+	if r.state.Settings.Infection {
+		r.state.Message = fmt.Sprintf("%s заразил игрока %s! Осталось спрятанных: %d", fallbackName(seeker.Name), fallbackName(player.Name), remaining)
+		return
 	}
-	r.state.Remaining = r.state.BombTimer
+	r.state.Message = fmt.Sprintf("%s нашёл игрока! Осталось спрятанных: %d", fallbackName(seeker.Name), remaining)
+}
 
-	holder, ok := r.players[r.state.BombHolder]
-	if !ok || holder == nil || !holder.Alive {
-		r.assignBombToRandomAliveLocked(true)
-	}
-	holder = r.players[r.state.BombHolder]
-	if holder != nil && holder.Alive && r.state.BombTimer <= 0 {
-		holder.Alive = false
-		holder.Moving = false
-		r.state.Message = fmt.Sprintf("%s не успел избавиться от бомбы!", fallbackName(holder.Name))
-		r.state.BombHolder = ""
-		r.state.BombTimer = bombTimerDuration
-	}
-	if r.countAlivePlayersLocked() <= 1 {
-		r.endRoundLocked("Выжил только один котик!")
-		return
+func (r *room) trackHiddenTimeLocked() {
+	for id, player := range r.players {
+		if r.isSeekerLocked(id) || player == nil || !player.Alive {
+			continue
+		}
+		r.roundStatsLocked(id).SecondsHidden += tickRate.Seconds()
 	}
-	if r.state.BombHolder == "" {
-		r.assignBombToRandomAliveLocked(true)
+}
+
+// pickAliveHiderLocked prefers the surviving hider with the most taunt
+// points.
+func (r *room) pickAliveHiderLocked() string {
+	best := ""
+	for _, id := range sortedPlayerIDs(r.players) {
+		player := r.players[id]
+		if r.isSeekerLocked(id) || player == nil || !player.Alive {
+			continue
+		}
+		if best == "" || player.Score > r.players[best].Score {
+			best = id
+		}
 	}
+	return best
 }
 
 func (r *room) updateFishLocked() {
@@ -1697,6 +2016,7 @@ func (r *room) updateFishLocked() {
 	}
 	world := r.currentWorldSize()
 	r.state.Fish.X = clampFloat(nextX, r.state.Fish.Size/2, world-r.state.Fish.Size/2)
+	r.pullFishWithMagnetLocked()
 
 	for _, p := range r.players {
 		if !p.Alive {
@@ -1704,13 +2024,67 @@ func (r *room) updateFishLocked() {
 		}
 		dist := math.Hypot(p.X-r.state.Fish.X, p.Y-r.state.Fish.Y)
 		if dist <= fishCatchDistance {
-			p.Score += 1
+			r.catchFishLocked(p)
 			r.spawnFishLocked()
 			break
 		}
 	}
 }
 
+func (r *room) catchFishLocked(p *playerState) {
+	settings := r.state.Settings
+	stats := r.roundStatsLocked(p.ID)
+	stats.FishCaught++
+	points := settings.FishPoints
+	switch r.state.Fish.Type {
+	case "golden":
+		points = settings.GoldenFishPoints
+		stats.GoldenFish++
+	case "timeIncrease":
+		r.state.Remaining += settings.TimeFishSeconds
+		r.state.Message = fmt.Sprintf("%s добавил %s к раунду", fallbackName(p.Name), formatSecondsRu(settings.TimeFishSeconds))
+	case "timeDecrease":
+		r.state.Remaining = math.Max(r.state.Remaining-settings.TimeFishSeconds, 1)
+		r.state.Message = fmt.Sprintf("%s сократил раунд на %s", fallbackName(p.Name), formatSecondsRu(settings.TimeFishSeconds))
+	}
+	if r.state.Golden {
+		points *= goldenChainMultiplier
+	}
+	p.Score += points
+	if r.state.Fish.Type == "golden" && settings.GoldenChainDuration > 0 {
+		r.goldenChainTimer = settings.GoldenChainDuration
+		r.state.Golden = true
+		r.state.Message = fmt.Sprintf("%s поймал золотую рыбку! Очки удваиваются", fallbackName(p.Name))
+	}
+	r.emitEventLocked(gameEvent{Type: "fishCaught", PlayerID: p.ID, Detail: r.state.Fish.Type})
+}
+
+func (r *room) updateGoldenChainLocked() {
+	if !r.state.Golden {
+		return
+	}
+	r.goldenChainTimer -= tickRate.Seconds()
+	if r.goldenChainTimer <= 0 {
+		r.goldenChainTimer = 0
+		r.state.Golden = false
+	}
+}
+
+func (r *room) rollFishTypeLocked() string {
+	settings := r.state.Settings
+	roll := rand.Float64()
+	if roll < settings.GoldenFishChance {
+		return "golden"
+	}
+	if roll < settings.GoldenFishChance+settings.TimeFishChance {
+		if rand.Float64() < 0.5 {
+			return "timeIncrease"
+		}
+		return "timeDecrease"
+	}
+	return "normal"
+}
+
 func (r *room) updatePowerUpLocked() {
 	if r.state.Phase != "playing" {
 		return
@@ -1726,40 +2100,53 @@ func (r *room) updatePowerUpLocked() {
 }
 
 func (r *room) updateStatusEffectLocked() {
-	if r.state.Status == nil {
-		return
-	}
-	r.state.Status.Remaining -= tickRate.Seconds()
-	if r.state.Status.Remaining <= 0 {
-		r.state.Status = nil
+	for _, p := range r.players {
+		if len(p.Effects) == 0 {
+			continue
+		}
+		active := p.Effects[:0]
+		for _, effect := range p.Effects {
+			effect.Remaining -= tickRate.Seconds()
+			if effect.Remaining > 0 {
+				active = append(active, effect)
+			}
+		}
+		if len(active) == 0 {
+			active = nil
+		}
+		p.Effects = active
 	}
 }
 
 func (r *room) getSpeedMultiplierLocked(playerID string) float64 {
 	multiplier := 1.0
-	if r.isHideSeekMode() && r.state.HidePhase == "seeking" && r.state.SeekerID == playerID {
+	if r.isHideSeekMode() && r.state.HidePhase == "seeking" && r.isSeekerLocked(playerID) {
+		if seeker, ok := r.players[playerID]; ok && seeker.AbilityCD > 0 {
+			return 0
+		}
 		multiplier *= hideSeekSeekerBoost
 	}
 
-	if r.state.Status == nil {
-		return multiplier
-	}
-	if r.state.Status.PlayerID != "" && r.state.Status.PlayerID != playerID {
+	p, ok := r.players[playerID]
+	if !ok {
 		return multiplier
 	}
-	switch r.state.Status.Type {
-	case "speedUp":
-		return multiplier * 2
-	case "speedDown":
-		return multiplier / 1.5
-	default:
-		return multiplier
+	for _, effect := range p.Effects {
+		if rule, ok := statusEffectRules[effect.Type]; ok && rule.Speed > 0 {
+			multiplier *= rule.Speed + rule.StackSpeed*float64(effect.Stacks-1)
+		}
 	}
+	return multiplier
 }
 
 func (r *room) spawnFishLocked() {
 	margin := 30.0
 	fish := &r.state.Fish
+	fishType := r.rollFishTypeLocked()
+	if r.activeMapLocked() != nil {
+		r.spawnFishOnMapLocked(fishType)
+		return
+	}
 	world := r.currentWorldSize()
 	alivePlayers := make([]*playerState, 0, len(r.players))
 	for _, p := range r.players {
@@ -1784,10 +2171,10 @@ func (r *room) spawnFishLocked() {
 		x := margin + rand.Float64()*(world-margin*2)
 		y := margin + rand.Float64()*(world-margin*2)
 		fishCell := positionToGridCell(x, y, world)
-		if containsCell(catCells, fishCell) {
+		if containsCell(catCells, fishCell) || (attempt < 150 && r.nearCatLocked(x, y, spawnFishClearance)) {
 			continue
 		}
-		candidateWalls := r.generateWallsLayoutForPlayers(catCells, fishCell)
+		candidateWalls, segments := r.generateWallsLayoutForPlayers(catCells, fishCell, nil)
 		if candidateWalls == nil {
 			continue
 		}
@@ -1795,13 +2182,14 @@ func (r *room) spawnFishLocked() {
 			continue
 		}
 		r.state.Walls = candidateWalls
+		r.setWallSegmentsLocked(segments, fishCell)
 		r.handlePowerUpAfterWallChangeLocked()
 		r.resolvePlayersAfterWallChangeLocked()
 		fish.X = x
 		fish.Y = y
 		fish.Alive = true
 		fish.Size = fishSize
-		fish.Type = "normal"
+		fish.Type = fishType
 		fish.Direction = 1
 		fish.Spawned = true
 		r.state.Mines = r.generateMinesLocked()
@@ -1812,13 +2200,14 @@ func (r *room) spawnFishLocked() {
 
 	if !placed {
 		r.state.Walls = nil
+		r.setWallSegmentsLocked(nil, positionToGridCell(world/2, world/2, world))
 		r.handlePowerUpAfterWallChangeLocked()
 		r.resolvePlayersAfterWallChangeLocked()
 		fish.X = world / 2
 		fish.Y = world / 2
 		fish.Alive = true
 		fish.Size = fishSize
-		fish.Type = "normal"
+		fish.Type = fishType
 		fish.Direction = 1
 		fish.Spawned = true
 		r.state.Mines = r.generateMinesLocked()
@@ -1854,9 +2243,9 @@ func (r *room) broadcastState() {
 	quantizeStateForSend(&stateCopy)
 	stateCopy.TickIndex = r.tickIndex
 	previous := r.lastBroadcastState
-	connections := make([]*websocket.Conn, 0, len(r.connections))
-	for conn := range r.connections {
-		connections = append(connections, conn)
+	connections := make([]*clientConn, 0, len(r.connections))
+	for client := range r.connections {
+		connections = append(connections, client)
 	}
 	stateSnapshot := stateCopy
 	stateSnapshot.Fish.Spawned = false
@@ -1878,17 +2267,17 @@ func (r *room) broadcastState() {
 	} else {
 		return
 	}
-	for _, conn := range connections {
-		conn.WriteMessage(websocket.BinaryMessage, data)
+	for _, client := range connections {
+		client.send(websocket.BinaryMessage, data)
 	}
 }
 
-func (r *room) broadcastJSONState(stateCopy gameState, previous *gameState, connections []*websocket.Conn) {
+func (r *room) broadcastJSONState(stateCopy gameState, previous *gameState, connections []*clientConn) {
 	if previous == nil {
 		payload := wsMessage{Type: "state", State: &stateCopy, Full: true}
 		data, _ := json.Marshal(payload)
-		for _, conn := range connections {
-			conn.WriteMessage(websocket.TextMessage, data)
+		for _, client := range connections {
+			client.send(websocket.TextMessage, data)
 		}
 		return
 	}
@@ -1896,17 +2285,17 @@ func (r *room) broadcastJSONState(stateCopy gameState, previous *gameState, conn
 	if patch := buildStatePatch(*previous, stateCopy); patch != nil {
 		payload := wsMessage{Type: "patch", Patch: patch}
 		data, _ := json.Marshal(payload)
-		for _, conn := range connections {
-			conn.WriteMessage(websocket.TextMessage, data)
+		for _, client := range connections {
+			client.send(websocket.TextMessage, data)
 		}
 	}
 }
 
-func (r *room) sendProtocolInfo(conn *websocket.Conn) {
+func (r *room) sendProtocolInfo(client *clientConn) {
 	binary := r.server.binaryProtocolEnabled()
 	payload := wsMessage{Type: "protocol", Binary: boolPtr(binary)}
 	data, _ := json.Marshal(payload)
-	conn.WriteMessage(websocket.TextMessage, data)
+	client.send(websocket.TextMessage, data)
 }
 
 func (r *room) broadcastChat(senderID string, msg chatMessage) {
@@ -1920,31 +2309,26 @@ func (r *room) broadcastChat(senderID string, msg chatMessage) {
 
 	msg.At = time.Now().UnixMilli()
 	data, _ := json.Marshal(wsMessage{Type: "chat", Message: &msg})
-	for conn, playerID := range r.connections {
+	for client, playerID := range r.connections {
 		if playerID == senderID {
 			continue
 		}
-		conn.WriteMessage(websocket.TextMessage, data)
+		client.send(websocket.TextMessage, data)
 	}
 }
 
-func (r *room) applyRandomStatusEffectLocked(ownerID string) {
-	effects := []string{"speedUp", "speedDown", "timeIncrease", "timeDecrease"}
-	if r.isBombMode() || ownerID != "" {
-		effects = []string{"speedUp", "speedDown"}
-	}
-	if len(effects) == 0 {
-		return
-	}
-	typeChoice := effects[rand.Intn(len(effects))]
-	r.state.Status = &statusEffect{Type: typeChoice, Remaining: powerUpDuration, PlayerID: ownerID}
-	if ownerID == "" {
-		if typeChoice == "timeIncrease" {
-			r.state.Remaining = math.Max(r.state.Remaining, timeIncreaseLimit)
-		} else if typeChoice == "timeDecrease" {
-			r.state.Remaining = math.Min(r.state.Remaining, timeDecreaseLimit)
+// addStatusEffect stacks an effect the player already has, up to the rule's
+// limit, and restarts its timer.
+func addStatusEffect(p *playerState, effectType string) {
+	rule := statusEffectRules[effectType]
+	for i := range p.Effects {
+		if p.Effects[i].Type == effectType {
+			p.Effects[i].Stacks = min(p.Effects[i].Stacks+1, max(rule.MaxStacks, 1))
+			p.Effects[i].Remaining = rule.Duration
+			return
 		}
 	}
+	p.Effects = append(p.Effects, statusEffect{Type: effectType, Remaining: rule.Duration, Stacks: 1})
 }
 
 func (r *room) clearPowerUpLocked() {
@@ -1955,20 +2339,16 @@ func (r *room) clearPowerUpLocked() {
 }
 
 func (r *room) spawnPowerUpLocked() {
-	margin := 36.0
-	world := r.currentWorldSize()
-	for attempt := 0; attempt < 40; attempt++ {
-		x := margin + rand.Float64()*(world-margin*2)
-		y := margin + rand.Float64()*(world-margin*2)
-		if !circleIntersectsAnyWall(x, y, r.state.PowerUp.Size/2+2, r.state.Walls) {
-			r.state.PowerUp.X = x
-			r.state.PowerUp.Y = y
-			r.state.PowerUp.Active = true
-			r.state.PowerUp.Remaining = powerUpLifetime
-			return
-		}
+	x, y, ok := r.itemPositionLocked(r.state.PowerUp.Size/2, 36, r.reachableAreaLocked())
+	if !ok {
+		r.clearPowerUpLocked()
+		return
 	}
-	r.clearPowerUpLocked()
+	r.state.PowerUp.X = x
+	r.state.PowerUp.Y = y
+	r.state.PowerUp.Active = true
+	r.state.PowerUp.Remaining = powerUpLifetime
+	r.state.PowerUp.Type = classicPowerUpTypes[rand.Intn(len(classicPowerUpTypes))]
 }
 
 func (r *room) refreshPowerUpLocked() {
@@ -1996,7 +2376,8 @@ func (r *room) collectBombPowerUpsLocked(player *playerState) {
 		dist := math.Hypot(player.X-pu.X, player.Y-pu.Y)
 		if dist < (player.Size+pu.Size)/2 {
 			r.state.PowerUps = append(r.state.PowerUps[:i], r.state.PowerUps[i+1:]...)
-			r.applyRandomStatusEffectLocked(player.ID)
+			r.applyPowerUpLocked(player, pu.Type)
+			r.emitEventLocked(gameEvent{Type: "powerUpCollected", PlayerID: player.ID})
 		}
 	}
 }
@@ -2028,19 +2409,13 @@ func (r *room) updateBombPowerUpLocked() {
 }
 
 func (r *room) spawnBombPowerUpLocked() bool {
-	margin := 36.0
-	world := r.currentWorldSize()
-	for attempt := 0; attempt < 60; attempt++ {
-		x := margin + rand.Float64()*(world-margin*2)
-		y := margin + rand.Float64()*(world-margin*2)
-		if circleIntersectsAnyWall(x, y, powerUpSize/2+2, r.state.Walls) {
-			continue
-		}
-		pu := powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: bombPowerUpLifetime}
-		r.state.PowerUps = append(r.state.PowerUps, pu)
-		return true
+	x, y, ok := r.itemPositionLocked(powerUpSize/2, 36, r.reachableAreaLocked())
+	if !ok {
+		return false
 	}
-	return false
+	pu := powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: bombPowerUpLifetime, Type: bombPowerUpTypes[rand.Intn(len(bombPowerUpTypes))]}
+	r.state.PowerUps = append(r.state.PowerUps, pu)
+	return true
 }
 
 func (r *room) resolvePlayersAfterWallChangeLocked() {
@@ -2059,6 +2434,10 @@ func (r *room) handlePowerUpAfterWallChangeLocked() {
 }
 
 func (r *room) buildArenaWithWallsLocked() {
+	if m := r.activeMapLocked(); m != nil {
+		r.applyMapWallsLocked(m)
+		return
+	}
 	world := r.currentWorldSize()
 	layout := r.buildBoundaryWalls(world)
 
@@ -2066,6 +2445,9 @@ func (r *room) buildArenaWithWallsLocked() {
 	for _, p := range r.players {
 		players = append(players, p)
 	}
+	n := gridDimension(world)
+	anchor := gridCell{Row: n / 2, Col: n / 2}
+	r.setWallSegmentsLocked(nil, anchor)
 	if len(players) == 0 {
 		r.state.Walls = layout
 		return
@@ -2076,13 +2458,13 @@ func (r *room) buildArenaWithWallsLocked() {
 		catCells = append(catCells, positionToGridCell(p.X, p.Y, world))
 	}
 
-	anchor := gridCell{Row: gridSize / 2, Col: gridSize / 2}
 	if containsCell(catCells, anchor) {
-		anchor = gridCell{Row: (gridSize / 2) - 1, Col: gridSize / 2}
+		anchor = gridCell{Row: n/2 - 1, Col: n / 2}
 	}
 
-	if candidate := r.generateWallsLayoutForPlayers(catCells, anchor); candidate != nil {
+	if candidate, segments := r.generateWallsLayoutForPlayers(catCells, anchor, layout); candidate != nil {
 		layout = append(layout, candidate...)
+		r.setWallSegmentsLocked(segments, anchor)
 	}
 
 	r.state.Walls = layout
@@ -2090,27 +2472,23 @@ func (r *room) buildArenaWithWallsLocked() {
 	r.resolvePlayersAfterWallChangeLocked()
 }
 
-func (r *room) generateWallsLayoutForPlayers(catCells []gridCell, fishCell gridCell) []wall {
+// generateWallsLayoutForPlayers lays out wall segments next to the fixed
+// walls already in the arena. Collapsing floor counts as blocked, so the
+// arena stays connected around it.
+func (r *room) generateWallsLayoutForPlayers(catCells []gridCell, fishCell gridCell, fixed []wall) ([]wall, []wallSegment) {
 	if len(catCells) == 0 {
-		return nil
+		return nil, nil
 	}
+	world := r.currentWorldSize()
+	base := buildBlockedGridFromSegments(nil, gridDimension(world))
+	markCrampedCells(base, fixed, world)
+	r.markHazardCellsLocked(base)
 	for attempt := 0; attempt < 160; attempt++ {
-		segments := r.buildRandomWallSegments(catCells, fishCell)
+		segments := r.buildRandomWallSegments(catCells, fishCell, base)
 		if segments == nil {
 			continue
 		}
-		blockedGrid := buildBlockedGridFromSegments(segments)
-		allReachable := true
-		for _, c := range catCells {
-			if !isPathAvailable(c, fishCell, blockedGrid) {
-				allReachable = false
-				break
-			}
-		}
-		if !allReachable {
-			continue
-		}
-		candidateWalls := convertSegmentsToWalls(segments, r.currentWorldSize(), r.wallThicknessRate())
+		candidateWalls := convertSegmentsToWalls(segments, world, r.wallThicknessRate())
 		intersectsPlayer := false
 		for _, p := range r.players {
 			if entityIntersectsWalls(p, candidateWalls) {
@@ -2121,12 +2499,15 @@ func (r *room) generateWallsLayoutForPlayers(catCells []gridCell, fishCell gridC
 		if intersectsPlayer {
 			continue
 		}
-		return candidateWalls
+		return candidateWalls, segments
 	}
-	return nil
+	return nil, nil
 }
 
-func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell) []wallSegment {
+// buildRandomWallSegments places segments on top of the base grid. Every
+// segment keeps all open cells connected to the objective, and segments
+// never cover cat cells, so every cat keeps a way to it.
+func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell, base [][]bool) []wallSegment {
 	segments := []wallSegment{}
 	occupied := make(map[string]struct{})
 	totalLength := 0
@@ -2137,11 +2518,20 @@ func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell) [
 		catKeys[cellKey(c)] = struct{}{}
 	}
 
-	maxLength := r.maxWallTotalLen()
-
-	for totalLength < maxLength && attempts < 80 {
+	// Wall limits are tuned for the base grid; finer grids get longer
+	// segments so the walls cover the same share of the arena.
+	world := r.currentWorldSize()
+	n := gridDimension(world)
+	scale := n / gridSize
+	maxLength := r.maxWallTotalLen() * scale
+	maxSegments := r.maxSegments() * scale
+	// A segment that would seal off part of the arena is skipped rather
+	// than failing the whole layout.
+	blocked := cloneGrid(base)
+
+	for totalLength < maxLength && attempts < 80*scale {
 		attempts++
-		if len(segments) >= r.maxSegments() && rand.Float64() < 0.35 {
+		if len(segments) >= maxSegments && rand.Float64() < 0.35 {
 			break
 		}
 		remaining := maxLength - totalLength
@@ -2149,19 +2539,19 @@ func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell) [
 			continue
 		}
 		maxSegmentLen := remaining
-		if maxSegmentLen > 3 {
-			maxSegmentLen = 3
+		if maxSegmentLen > 3*scale {
+			maxSegmentLen = 3 * scale
 		}
 		length := 1 + rand.Intn(int(maxSegmentLen))
 		orientation := "horizontal"
 		if rand.Float64() < 0.5 {
 			orientation = "vertical"
 		}
-		maxRow := gridSize - 1
-		maxCol := gridSize - length
+		maxRow := n - 1
+		maxCol := n - length
 		if orientation == "vertical" {
-			maxRow = gridSize - length
-			maxCol = gridSize - 1
+			maxRow = n - length
+			maxCol = n - 1
 		}
 		if maxRow < 0 || maxCol < 0 {
 			continue
@@ -2184,10 +2574,20 @@ func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell) [
 		if invalid {
 			continue
 		}
+		segment := wallSegment{Row: row, Col: col, Length: length, Orientation: orientation}
+		trial := cloneGrid(blocked)
+		for _, cell := range cells {
+			trial[cell.Row][cell.Col] = true
+		}
+		markCrampedCells(trial, convertSegmentsToWalls([]wallSegment{segment}, world, r.wallThicknessRate()), world)
+		if !openCellsConnected(fishCell, trial) || cellsNewlyBlocked(catCells, blocked, trial) {
+			continue
+		}
+		blocked = trial
 		for _, cell := range cells {
 			occupied[cellKey(cell)] = struct{}{}
 		}
-		segments = append(segments, wallSegment{Row: row, Col: col, Length: length, Orientation: orientation})
+		segments = append(segments, segment)
 		totalLength += length
 	}
 
@@ -2199,7 +2599,7 @@ func (r *room) buildRandomWallSegments(catCells []gridCell, fishCell gridCell) [
 
 func (r *room) generateMinesLocked() []mine {
 	result := []mine{}
-	mineCount := rand.Intn(maxMines + 1)
+	mineCount := rand.Intn(r.state.Settings.MaxMines + 1)
 	if mineCount == 0 {
 		return result
 	}
@@ -2253,20 +2653,27 @@ func (r *room) isMinePositionValidLocked(x, y, radius float64, existing []mine)
 	return true
 }
 
-func clampGridIndex(v int) int {
+// gridDimension is the number of wall grid cells per side. Cells keep the
+// size they have in the base world, so larger arenas get finer grids.
+func gridDimension(world float64) int {
+	return max(gridSize, int(math.Round(world/gridCellSize)))
+}
+
+func clampGridIndex(v, n int) int {
 	if v < 0 {
 		return 0
 	}
-	if v >= gridSize {
-		return gridSize - 1
+	if v >= n {
+		return n - 1
 	}
 	return v
 }
 
 func positionToGridCell(x, y, world float64) gridCell {
-	cellSize := world / gridSize
-	col := clampGridIndex(int(math.Floor(x / cellSize)))
-	row := clampGridIndex(int(math.Floor(y / cellSize)))
+	n := gridDimension(world)
+	cellSize := world / float64(n)
+	col := clampGridIndex(int(math.Floor(x/cellSize)), n)
+	row := clampGridIndex(int(math.Floor(y/cellSize)), n)
 	return gridCell{Row: row, Col: col}
 }
 
@@ -2285,10 +2692,10 @@ func getCellsForSegment(row, col, length int, orientation string) []gridCell {
 	return cells
 }
 
-func buildBlockedGridFromSegments(segments []wallSegment) [][]bool {
-	grid := make([][]bool, gridSize)
+func buildBlockedGridFromSegments(segments []wallSegment, n int) [][]bool {
+	grid := make([][]bool, n)
 	for i := range grid {
-		grid[i] = make([]bool, gridSize)
+		grid[i] = make([]bool, n)
 	}
 	for _, seg := range segments {
 		for offset := 0; offset < seg.Length; offset++ {
@@ -2299,7 +2706,7 @@ func buildBlockedGridFromSegments(segments []wallSegment) [][]bool {
 			} else {
 				row += offset
 			}
-			if row >= 0 && row < gridSize && col >= 0 && col < gridSize {
+			if row >= 0 && row < n && col >= 0 && col < n {
 				grid[row][col] = true
 			}
 		}
@@ -2307,7 +2714,49 @@ func buildBlockedGridFromSegments(segments []wallSegment) [][]bool {
 	return grid
 }
 
+func cloneGrid(grid [][]bool) [][]bool {
+	clone := make([][]bool, len(grid))
+	for i := range grid {
+		clone[i] = append([]bool(nil), grid[i]...)
+	}
+	return clone
+}
+
+// cellsNewlyBlocked reports whether any of the cells is open before and
+// blocked after.
+func cellsNewlyBlocked(cells []gridCell, before, after [][]bool) bool {
+	for _, c := range cells {
+		if !before[c.Row][c.Col] && after[c.Row][c.Col] {
+			return true
+		}
+	}
+	return false
+}
+
+// markCrampedCells blocks every cell whose centre is closer than a cat's
+// radius to a wall. Walls thicker than a cell spill into the cells beside
+// them, which the segment grid alone would count as open.
+func markCrampedCells(blocked [][]bool, walls []wall, world float64) {
+	n := len(blocked)
+	cellSize := world / float64(n)
+	radius := catSize / 2
+	for _, w := range walls {
+		minRow := clampGridIndex(int(math.Floor((w.Y-radius)/cellSize)), n)
+		maxRow := clampGridIndex(int(math.Floor((w.Y+w.Height+radius)/cellSize)), n)
+		minCol := clampGridIndex(int(math.Floor((w.X-radius)/cellSize)), n)
+		maxCol := clampGridIndex(int(math.Floor((w.X+w.Width+radius)/cellSize)), n)
+		for row := minRow; row <= maxRow; row++ {
+			for col := minCol; col <= maxCol; col++ {
+				if circleIntersectsRect((float64(col)+0.5)*cellSize, (float64(row)+0.5)*cellSize, radius, w) {
+					blocked[row][col] = true
+				}
+			}
+		}
+	}
+}
+
 func isPathAvailable(catCell, fishCell gridCell, blocked [][]bool) bool {
+	n := len(blocked)
 	startKey := cellKey(catCell)
 	targetKey := cellKey(fishCell)
 	visited := map[string]struct{}{startKey: {}}
@@ -2324,7 +2773,7 @@ func isPathAvailable(catCell, fishCell gridCell, blocked [][]bool) bool {
 		for _, delta := range deltas {
 			nextRow := current.Row + delta[0]
 			nextCol := current.Col + delta[1]
-			if nextRow < 0 || nextRow >= gridSize || nextCol < 0 || nextCol >= gridSize {
+			if nextRow < 0 || nextRow >= n || nextCol < 0 || nextCol >= n {
 				continue
 			}
 			if blocked[nextRow][nextCol] {
@@ -2342,10 +2791,58 @@ func isPathAvailable(catCell, fishCell gridCell, blocked [][]bool) bool {
 	return false
 }
 
+// reachableCells flood-fills the open cells from start.
+func reachableCells(start gridCell, blocked [][]bool) [][]bool {
+	n := len(blocked)
+	reached := make([][]bool, n)
+	for i := range reached {
+		reached[i] = make([]bool, n)
+	}
+	reached[start.Row][start.Col] = true
+	queue := []gridCell{start}
+	deltas := [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, delta := range deltas {
+			row, col := current.Row+delta[0], current.Col+delta[1]
+			if row < 0 || row >= n || col < 0 || col >= n || blocked[row][col] || reached[row][col] {
+				continue
+			}
+			reached[row][col] = true
+			queue = append(queue, gridCell{Row: row, Col: col})
+		}
+	}
+	return reached
+}
+
+// openCellsConnected reports whether every open cell can be reached from
+// start, i.e. the walls seal off no pocket of the arena.
+func openCellsConnected(start gridCell, blocked [][]bool) bool {
+	if blocked[start.Row][start.Col] {
+		return false
+	}
+	reached := reachableCells(start, blocked)
+	for row := range blocked {
+		for col := range blocked[row] {
+			if !blocked[row][col] && !reached[row][col] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segmentWallThickness keeps walls as thick as on the base grid, so on
+// finer grids they are wider than a cell.
+func segmentWallThickness(world, thicknessRate float64) float64 {
+	return world / gridSize * thicknessRate
+}
+
 func convertSegmentsToWalls(segments []wallSegment, world float64, thicknessRate float64) []wall {
 	walls := make([]wall, 0, len(segments))
-	cellSize := world / gridSize
-	thickness := cellSize * thicknessRate
+	cellSize := world / float64(gridDimension(world))
+	thickness := segmentWallThickness(world, thicknessRate)
 	for _, seg := range segments {
 		if seg.Orientation == "horizontal" {
 			walls = append(walls, wall{
@@ -2367,7 +2864,11 @@ func convertSegmentsToWalls(segments []wallSegment, world float64, thicknessRate
 }
 
 func (r *room) buildBoundaryWalls(world float64) []wall {
-	thickness := (world / gridSize) * r.wallThicknessRate()
+	return boundaryWalls(world, r.wallThicknessRate())
+}
+
+func boundaryWalls(world, thicknessRate float64) []wall {
+	thickness := segmentWallThickness(world, thicknessRate)
 	return []wall{
 		{X: 0, Y: 0, Width: world, Height: thickness},
 		{X: 0, Y: world - thickness, Width: world, Height: thickness},
@@ -2437,25 +2938,31 @@ func (r *room) hasLineOfSight(fromX, fromY, toX, toY float64) bool {
 }
 
 func (r *room) findWallIntersection(fromX, fromY, toX, toY float64) (float64, float64, bool) {
-	var closestT float64 = 1.1
-	hit := false
-	for _, w := range r.state.Walls {
-		if pointInsideRect(fromX, fromY, w) {
-			continue
-		}
-		intersects, t := lineIntersectsRect(fromX, fromY, toX, toY, w)
-		if intersects && t >= 0 && t < closestT {
-			closestT = t
-			hit = true
-		}
-	}
+	t, _, hit := sweepCircle(fromX, fromY, 0, toX-fromX, toY-fromY, r.state.Walls)
 	if !hit {
 		return 0, 0, false
 	}
+	return fromX + (toX-fromX)*t, fromY + (toY-fromY)*t, true
+}
 
-	hitX := fromX + (toX-fromX)*closestT
-	hitY := fromY + (toY-fromY)*closestT
-	return hitX, hitY, true
+// rayCircleIntersection returns the distance along the unit direction
+// (dirX, dirY) at which the ray first touches the circle.
+func rayCircleIntersection(fromX, fromY, dirX, dirY, cx, cy, radius float64) (float64, bool) {
+	ox := cx - fromX
+	oy := cy - fromY
+	along := ox*dirX + oy*dirY
+	perpSquared := ox*ox + oy*oy - along*along
+	if perpSquared > radius*radius {
+		return 0, false
+	}
+	entry := along - math.Sqrt(radius*radius-perpSquared)
+	if entry < 0 {
+		if along < 0 {
+			return 0, false
+		}
+		entry = 0
+	}
+	return entry, true
 }
 
 func circleIntersectsAnyWall(cx, cy, radius float64, walls []wall) bool {
@@ -2473,6 +2980,11 @@ func (r *room) fishCollidesAt(x float64) bool {
 	if x-radius < 0 || x+radius > world {
 		return true
 	}
+	// The sweep ignores walls the fish already overlaps, so the target spot
+	// is checked on its own as well.
+	if _, _, hit := sweepCircle(r.state.Fish.X, r.state.Fish.Y, radius, x-r.state.Fish.X, 0, r.state.Walls); hit {
+		return true
+	}
 	if circleIntersectsAnyWall(x, r.state.Fish.Y, radius, r.state.Walls) {
 		return true
 	}
@@ -2549,11 +3061,20 @@ func entityIntersectsWalls(entity *playerState, walls []wall) bool {
 func main() {
 	rand.Seed(time.Now().UnixNano())
 	srv := newServer()
+	go srv.matchmaker.run()
 
 	http.Handle("/api/cats/{id}", withCORS(http.HandlerFunc(srv.handleCats)))
 	http.Handle("/api/scores", withCORS(http.HandlerFunc(srv.handleScores)))
+	http.Handle("/api/players/{id}/rating", withCORS(http.HandlerFunc(srv.handlePlayerRating)))
+	http.Handle("/api/players/{id}/stats", withCORS(http.HandlerFunc(srv.handlePlayerStats)))
+	http.Handle("/api/players/{id}/achievements", withCORS(http.HandlerFunc(srv.handlePlayerAchievements)))
+	http.Handle("/api/maps", withCORS(http.HandlerFunc(srv.handleMaps)))
+	http.Handle("/api/maps/{id}", withCORS(http.HandlerFunc(srv.handleMap)))
+	http.Handle("/api/maps/{id}/rating", withCORS(http.HandlerFunc(srv.handleMapRating)))
+	http.Handle("/api/maps/{id}/approval", withCORS(http.HandlerFunc(srv.handleMapApproval)))
 	http.Handle("/api/rooms", withCORS(http.HandlerFunc(srv.handleRooms)))
 	http.Handle("/ws", withCORS(http.HandlerFunc(srv.handleWS))) // можно и без CORS, но не помешает
+	http.Handle("/ws/queue", withCORS(http.HandlerFunc(srv.handleQueue)))
 
 	addr := ":8080"
 	log.Printf("Cat game server listening on %s", addr)
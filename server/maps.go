@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// arenaMap is a handcrafted arena layout loaded from a JSON file. Walls are
+// free rectangles in world coordinates, Segments use the wall grid; both
+// may be combined. ItemZones limit where fish and power-ups appear.
+type arenaMap struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	WorldScale float64       `json:"worldScale"`
+	Boundary   bool          `json:"boundary"`
+	Walls      []wall        `json:"walls,omitempty"`
+	Segments   []wallSegment `json:"segments,omitempty"`
+	Spawns     []vector      `json:"spawns"`
+	Mines      []vector      `json:"mines,omitempty"`
+	ItemZones  []wall        `json:"itemZones,omitempty"`
+	Modes      []string      `json:"modes"`
+}
+
+func (m *arenaMap) worldSize() float64 {
+	return worldSize * m.WorldScale
+}
+
+func (m *arenaMap) supportsMode(mode string) bool {
+	for _, name := range m.Modes {
+		if name == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedWalls returns the border and the free rectangles.
+func (m *arenaMap) fixedWalls(mode GameMode) []wall {
+	var walls []wall
+	if m.Boundary {
+		walls = append(walls, boundaryWalls(m.worldSize(), mode.WallParams().ThicknessRate)...)
+	}
+	return append(walls, m.Walls...)
+}
+
+// wallsFor puts the grid segments last, like generated layouts, so arena
+// events can slide them.
+func (m *arenaMap) wallsFor(mode GameMode) []wall {
+	segments := convertSegmentsToWalls(m.Segments, m.worldSize(), mode.WallParams().ThicknessRate)
+	return append(m.fixedWalls(mode), segments...)
+}
+
+func rectInsideWorld(rect wall, world float64) bool {
+	return rect.Width > 0 && rect.Height > 0 && rect.X >= 0 && rect.Y >= 0 && rect.X+rect.Width <= world && rect.Y+rect.Height <= world
+}
+
+func circleInsideWorld(x, y, radius, world float64) bool {
+	return x-radius >= 0 && y-radius >= 0 && x+radius <= world && y+radius <= world
+}
+
+// blockedGridFromWalls marks every grid cell where a cat fits at none of a
+// few sample points.
+func blockedGridFromWalls(walls []wall, world float64) [][]bool {
+	const samples = 5
+	n := gridDimension(world)
+	cellSize := world / float64(n)
+	grid := make([][]bool, n)
+	for row := range grid {
+		grid[row] = make([]bool, n)
+		for col := range grid[row] {
+			grid[row][col] = true
+			for i := 0; i < samples*samples && grid[row][col]; i++ {
+				x := (float64(col) + (float64(i%samples)+0.5)/samples) * cellSize
+				y := (float64(row) + (float64(i/samples)+0.5)/samples) * cellSize
+				if circleInsideWorld(x, y, catSize/2, world) && !circleIntersectsAnyWall(x, y, catSize/2, walls) {
+					grid[row][col] = false
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// validate checks the layout for every mode the map supports. Reachability
+// is checked on the wall grid, so a pocket cut inside a single cell by free
+// rectangles can slip through.
+func (m *arenaMap) validate() error {
+	if m.ID == "" {
+		return fmt.Errorf("map id is required")
+	}
+	if err := checkFloatRange("worldScale", m.WorldScale, 1, 8); err != nil {
+		return err
+	}
+	if len(m.Modes) == 0 {
+		return fmt.Errorf("map must list at least one mode")
+	}
+	for _, name := range m.Modes {
+		if _, ok := lookupMode(name); !ok {
+			return fmt.Errorf("unknown mode %q", name)
+		}
+	}
+	world := m.worldSize()
+	for i, seg := range m.Segments {
+		if seg.Orientation != "horizontal" && seg.Orientation != "vertical" {
+			return fmt.Errorf("segment %d has invalid orientation %q", i, seg.Orientation)
+		}
+		if seg.Length < 1 || !segmentInsideGrid(getCellsForSegment(seg.Row, seg.Col, seg.Length, seg.Orientation), gridDimension(world)) {
+			return fmt.Errorf("segment %d is outside the grid", i)
+		}
+	}
+	for i, w := range m.Walls {
+		if !rectInsideWorld(w, world) {
+			return fmt.Errorf("wall %d is outside the world", i)
+		}
+	}
+	for i, zone := range m.ItemZones {
+		if !rectInsideWorld(zone, world) {
+			return fmt.Errorf("item zone %d is outside the world", i)
+		}
+	}
+	if len(m.Spawns) == 0 {
+		return fmt.Errorf("map needs at least one spawn point")
+	}
+	for _, name := range m.Modes {
+		mode, _ := lookupMode(name)
+		walls := m.wallsFor(mode)
+		for i, sp := range m.Spawns {
+			if !circleInsideWorld(sp.X, sp.Y, catSize/2, world) || circleIntersectsAnyWall(sp.X, sp.Y, catSize/2, walls) {
+				return fmt.Errorf("spawn %d is inside a wall or outside the world", i)
+			}
+		}
+		for i, mn := range m.Mines {
+			if !circleInsideWorld(mn.X, mn.Y, mineSize/2, world) || circleIntersectsAnyWall(mn.X, mn.Y, mineSize/2, walls) {
+				return fmt.Errorf("mine %d is inside a wall or outside the world", i)
+			}
+		}
+		// Segment cells are blocked as in generated layouts; cells crossed
+		// by other walls only when no cat fits in them. A spawn proves its
+		// cell has room for a cat.
+		blocked := blockedGridFromWalls(m.fixedWalls(mode), world)
+		for row, cells := range buildBlockedGridFromSegments(m.Segments, gridDimension(world)) {
+			for col, segment := range cells {
+				blocked[row][col] = blocked[row][col] || segment
+			}
+		}
+		spawnCells := make([]gridCell, len(m.Spawns))
+		for i, sp := range m.Spawns {
+			spawnCells[i] = positionToGridCell(sp.X, sp.Y, world)
+			blocked[spawnCells[i].Row][spawnCells[i].Col] = false
+		}
+		start := spawnCells[0]
+		for i, cell := range spawnCells[1:] {
+			if !isPathAvailable(start, cell, blocked) {
+				return fmt.Errorf("spawn %d cannot reach spawn 0 in mode %s", i+1, name)
+			}
+		}
+		for i, zone := range m.ItemZones {
+			center := positionToGridCell(zone.X+zone.Width/2, zone.Y+zone.Height/2, world)
+			if !isPathAvailable(start, center, blocked) {
+				return fmt.Errorf("item zone %d is unreachable in mode %s", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+func readArenaMap(path string) (*arenaMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m arenaMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid map file: %w", err)
+	}
+	if m.ID == "" {
+		m.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if m.Name == "" {
+		m.Name = m.ID
+	}
+	if m.WorldScale == 0 {
+		m.WorldScale = 1
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// loadArenaMaps reads every *.json file in dir. Invalid maps are logged and
+// skipped; a missing directory simply means there are no maps.
+func loadArenaMaps(dir string) map[string]*arenaMap {
+	maps := make(map[string]*arenaMap)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("failed to read maps directory: %v", err)
+		}
+		return maps
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		m, err := readArenaMap(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Printf("skipping map %s: %v", entry.Name(), err)
+			continue
+		}
+		if _, exists := maps[m.ID]; exists {
+			log.Printf("skipping map %s: duplicate id %q", entry.Name(), m.ID)
+			continue
+		}
+		maps[m.ID] = m
+	}
+	return maps
+}
+
+func (s *server) lookupMap(id string) (*arenaMap, bool) {
+	s.mapsMu.RLock()
+	defer s.mapsMu.RUnlock()
+	if m, ok := s.maps[id]; ok {
+		return m, true
+	}
+	if entry, ok := s.communityMaps[id]; ok && entry.Approved {
+		return entry.Map, true
+	}
+	return nil, false
+}
+
+// checkMapSettings makes sure the selected map exists and supports the mode,
+// and takes the world size from the map.
+func (s *server) checkMapSettings(mode string, settings *roomSettings) error {
+	if settings.Map == "" {
+		return nil
+	}
+	m, ok := s.lookupMap(settings.Map)
+	if !ok {
+		return fmt.Errorf("unknown map %q", settings.Map)
+	}
+	if !m.supportsMode(mode) {
+		return fmt.Errorf("map %q does not support mode %s", settings.Map, mode)
+	}
+	settings.WorldScale = m.WorldScale
+	return nil
+}
+
+// activeMapLocked returns the handcrafted map selected for the room, or nil
+// when the arena is generated.
+func (r *room) activeMapLocked() *arenaMap {
+	if r.state.Settings.Map == "" || r.server == nil {
+		return nil
+	}
+	m, ok := r.server.lookupMap(r.state.Settings.Map)
+	if !ok || !m.supportsMode(r.state.Mode) {
+		return nil
+	}
+	return m
+}
+
+// applyMapWallsLocked replaces walls and mines with the map layout.
+func (r *room) applyMapWallsLocked(m *arenaMap) {
+	r.state.Settings.WorldScale = m.WorldScale
+	r.state.Walls = m.wallsFor(r.mode())
+	objective := positionToGridCell(m.Spawns[0].X, m.Spawns[0].Y, m.worldSize())
+	r.setWallSegmentsLocked(append([]wallSegment(nil), m.Segments...), objective)
+	r.state.Mines = make([]mine, 0, len(m.Mines))
+	for _, mn := range m.Mines {
+		r.state.Mines = append(r.state.Mines, mine{X: mn.X, Y: mn.Y, Size: mineSize})
+	}
+	r.handlePowerUpAfterWallChangeLocked()
+	r.resolvePlayersAfterWallChangeLocked()
+}
+
+// randomItemPositionLocked picks a spot for an item: inside one of the map's
+// item zones, or anywhere in the arena away from the edge.
+func (r *room) randomItemPositionLocked(margin float64) (float64, float64) {
+	if m := r.activeMapLocked(); m != nil && len(m.ItemZones) > 0 {
+		zone := m.ItemZones[rand.Intn(len(m.ItemZones))]
+		return zone.X + rand.Float64()*zone.Width, zone.Y + rand.Float64()*zone.Height
+	}
+	world := r.currentWorldSize()
+	return margin + rand.Float64()*(world-margin*2), margin + rand.Float64()*(world-margin*2)
+}
+
+// spawnFishOnMapLocked places the classic fish on a fixed map without
+// regenerating the layout.
+func (r *room) spawnFishOnMapLocked(fishType string) {
+	world := r.currentWorldSize()
+	x, y := world/2, world/2
+	reach := r.reachableAreaLocked()
+	for attempt := 0; attempt < 200; attempt++ {
+		cx, cy := r.randomItemPositionLocked(30)
+		if r.itemSpotFreeLocked(cx, cy, fishSize/2, reach) && (attempt >= 150 || !r.nearCatLocked(cx, cy, spawnFishClearance)) {
+			x, y = cx, cy
+			break
+		}
+	}
+	r.state.Fish = fishState{X: x, Y: y, Size: fishSize, Alive: true, Spawned: true, Type: fishType, Direction: 1}
+	r.refreshPowerUpLocked()
+}
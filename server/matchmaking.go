@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// matchRule sizes matchmaking rooms: a match starts right away at Ideal
+// players, or with at least Min once the first player waited long enough.
+type matchRule struct {
+	Min   int
+	Ideal int
+	Max   int
+}
+
+// modeMatchRule returns the zero rule for modes the matchmaker cannot fill.
+func modeMatchRule(name string) matchRule {
+	if mode, ok := lookupMode(name); ok {
+		return mode.MatchRule()
+	}
+	return matchRule{}
+}
+
+type queueEntry struct {
+	playerID string
+	conn     *websocket.Conn
+	joinedAt time.Time
+	// writeMu serialises writes to conn; they happen outside the
+	// matchmaker lock and may come from the ticker and a new enqueue.
+	writeMu sync.Mutex
+}
+
+// queueWrite is a message for a queued player, sent once the matchmaker
+// lock is released so that a slow client cannot stall the queue.
+type queueWrite struct {
+	entry *queueEntry
+	msg   wsMessage
+	close bool
+}
+
+type matchRoom struct {
+	name     string
+	mode     string
+	assigned map[string]struct{}
+}
+
+type queueStatus struct {
+	Mode     string  `json:"mode"`
+	Position int     `json:"position"`
+	Waiting  int     `json:"waiting"`
+	Waited   float64 `json:"waited"`
+	Needed   int     `json:"needed"`
+}
+
+type matchmaker struct {
+	server *server
+	mu     sync.Mutex
+	queues map[string][]*queueEntry
+	rooms  map[string]*matchRoom
+	seq    int
+	outbox []queueWrite
+}
+
+func newMatchmaker(srv *server) *matchmaker {
+	return &matchmaker{
+		server: srv,
+		queues: make(map[string][]*queueEntry),
+		rooms:  make(map[string]*matchRoom),
+	}
+}
+
+func (m *matchmaker) run() {
+	ticker := time.NewTicker(matchmakingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.mu.Lock()
+		for mode := range m.queues {
+			m.processModeLocked(mode, time.Now())
+		}
+		m.unlockAndFlush()
+	}
+}
+
+func (m *matchmaker) enqueue(entry *queueEntry, mode string) {
+	m.mu.Lock()
+	m.removeLocked(entry.playerID)
+	m.queues[mode] = append(m.queues[mode], entry)
+	m.processModeLocked(mode, time.Now())
+	m.unlockAndFlush()
+}
+
+// unlockAndFlush releases the matchmaker lock and then delivers the queued
+// messages.
+func (m *matchmaker) unlockAndFlush() {
+	outbox := m.outbox
+	m.outbox = nil
+	m.mu.Unlock()
+	for _, w := range outbox {
+		w.entry.writeMu.Lock()
+		writeQueueMessage(w.entry.conn, w.msg)
+		if w.close {
+			w.entry.conn.Close()
+		}
+		w.entry.writeMu.Unlock()
+	}
+}
+
+func (m *matchmaker) sendLocked(entry *queueEntry, msg wsMessage, close bool) {
+	m.outbox = append(m.outbox, queueWrite{entry: entry, msg: msg, close: close})
+}
+
+func (m *matchmaker) remove(conn *websocket.Conn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for mode, queue := range m.queues {
+		for i, entry := range queue {
+			if entry.conn == conn {
+				m.queues[mode] = append(queue[:i], queue[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *matchmaker) removeLocked(playerID string) {
+	for mode, queue := range m.queues {
+		for i, entry := range queue {
+			if entry.playerID == playerID {
+				entry.conn.Close()
+				m.queues[mode] = append(queue[:i], queue[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *matchmaker) processModeLocked(mode string, now time.Time) {
+	rule := modeMatchRule(mode)
+	if rule.Ideal <= 0 {
+		return
+	}
+	m.fillOpenRoomsLocked(mode)
+
+	for len(m.queues[mode]) >= rule.Ideal {
+		m.startMatchLocked(mode, rule.Ideal)
+	}
+
+	queue := m.queues[mode]
+	if len(queue) > 0 {
+		waited := now.Sub(queue[0].joinedAt)
+		// After the wait limit a match starts with Min players; after the
+		// fallback wait it starts with whoever is there and fills up from
+		// the queue while the room sits in the lobby.
+		if (len(queue) >= rule.Min && waited >= matchmakingWaitLimit) || waited >= matchmakingFallbackWait {
+			m.startMatchLocked(mode, min(len(queue), rule.Max))
+		}
+	}
+
+	queue = m.queues[mode]
+	for i, entry := range queue {
+		status := &queueStatus{
+			Mode:     mode,
+			Position: i + 1,
+			Waiting:  len(queue),
+			Waited:   roundFloat(now.Sub(entry.joinedAt).Seconds(), 1),
+			Needed:   rule.Min,
+		}
+		m.sendLocked(entry, wsMessage{Type: "queue", Queue: status}, false)
+	}
+}
+
+// fillOpenRoomsLocked tops up matchmaking rooms that are still waiting in the
+// lobby before any new room is created. Each waiting player goes to the open
+// room whose average rating is closest to their own.
+func (m *matchmaker) fillOpenRoomsLocked(mode string) {
+	if len(m.queues[mode]) == 0 {
+		return
+	}
+	rule := modeMatchRule(mode)
+	type openRoom struct {
+		room    *matchRoom
+		free    int
+		average float64
+	}
+	open := []*openRoom{}
+	for name, mr := range m.rooms {
+		if mr.mode != mode {
+			continue
+		}
+		r := m.server.lookupRoom(name)
+		if r == nil {
+			delete(m.rooms, name)
+			continue
+		}
+		ids, accepting := r.occupancy(mr.assigned)
+		if !accepting {
+			if len(ids) == 0 {
+				delete(m.rooms, name)
+			}
+			continue
+		}
+		if free := rule.Max - len(ids); free > 0 {
+			open = append(open, &openRoom{room: mr, free: free, average: m.server.averageRating(ids, mode)})
+		}
+	}
+	if len(open) == 0 {
+		return
+	}
+
+	waiting := m.queues[mode][:0]
+	for _, entry := range m.queues[mode] {
+		rating := m.server.playerRating(entry.playerID, mode)
+		var best *openRoom
+		for _, candidate := range open {
+			if candidate.free == 0 {
+				continue
+			}
+			if best == nil || math.Abs(candidate.average-rating) < math.Abs(best.average-rating) {
+				best = candidate
+			}
+		}
+		if best == nil {
+			waiting = append(waiting, entry)
+			continue
+		}
+		m.assignLocked(best.room, entry)
+		best.free--
+	}
+	m.queues[mode] = waiting
+}
+
+func (m *matchmaker) startMatchLocked(mode string, count int) {
+	queue := m.queues[mode]
+	if count > len(queue) {
+		count = len(queue)
+	}
+	if count == 0 {
+		return
+	}
+	m.seq++
+	name := fmt.Sprintf("match-%s-%d", mode, m.seq)
+	m.server.getOrCreateRoom(name, mode, nil)
+	mr := &matchRoom{name: name, mode: mode, assigned: make(map[string]struct{})}
+	m.rooms[name] = mr
+	for _, entry := range queue[:count] {
+		m.assignLocked(mr, entry)
+	}
+	m.queues[mode] = queue[count:]
+}
+
+func (m *matchmaker) assignLocked(mr *matchRoom, entry *queueEntry) {
+	mr.assigned[entry.playerID] = struct{}{}
+	m.sendLocked(entry, wsMessage{Type: "match", Room: mr.name, Mode: mr.mode}, true)
+}
+
+func writeQueueMessage(conn *websocket.Conn, msg wsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (s *server) lookupRoom(name string) *room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rooms[name]
+}
+
+// occupancy lists connected players plus the ones the matchmaker already
+// sent here and reports whether the room still accepts newcomers.
+func (r *room) occupancy(assigned map[string]struct{}) ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.players)+len(assigned))
+	for id := range r.players {
+		ids = append(ids, id)
+	}
+	for id := range assigned {
+		if _, ok := r.players[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, r.state.Phase == "lobby"
+}
+
+func (s *server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	playerID := r.URL.Query().Get("playerId")
+	mode := normalizeMode(r.URL.Query().Get("mode"))
+	if playerID == "" {
+		http.Error(w, "playerId required", http.StatusBadRequest)
+		return
+	}
+	if modeMatchRule(mode).Ideal <= 0 {
+		http.Error(w, "mode is not available for matchmaking", http.StatusBadRequest)
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("upgrade error: %v", err)
+		return
+	}
+	s.matchmaker.enqueue(&queueEntry{playerID: playerID, conn: conn, joinedAt: time.Now()}, mode)
+
+	go func() {
+		defer func() {
+			conn.Close()
+			s.matchmaker.remove(conn)
+		}()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"catgame/protocol"
+)
+
+// GameMode holds everything that differs between game modes. The room
+// drives the shared parts (countdown, movement, mines, patches) and calls
+// into the mode for the rest. Every hook runs under the room lock.
+type GameMode interface {
+	Name() string
+	// Defaults adjusts the default room settings, e.g. world scale and
+	// round duration.
+	Defaults(settings *roomSettings)
+	WallParams() wallParams
+	// ItemTypes lists the power-up types the mode puts into the state
+	// together with their binary protocol codes.
+	ItemTypes() map[string]uint8
+	StartRound(r *room)
+	// Tick advances one playing tick and ends the round when it is over.
+	Tick(r *room)
+	Pickup(r *room, p *playerState)
+	Collide(r *room)
+	Winner(r *room) string
+	// MatchRule sizes the rooms the matchmaker opens for the mode.
+	MatchRule() matchRule
+	// Teams tells whether and how the mode plays in teams.
+	Teams(settings roomSettings) teamRule
+	// Ranking orders the players of a finished free-for-all round from best
+	// to worst. Players sharing a tier are treated as a draw.
+	Ranking(r *room) [][]string
+}
+
+type wallParams struct {
+	ThicknessRate float64
+	MaxTotalLen   int
+	MaxSegments   int
+}
+
+var arenaWallParams = wallParams{ThicknessRate: bombWallThicknessRate, MaxTotalLen: bombMaxWallTotalLen, MaxSegments: bombMaxSegments}
+
+var gameModes = make(map[string]GameMode)
+
+// registerMode makes a mode selectable by name. Modes call it from init.
+func registerMode(mode GameMode) {
+	name := mode.Name()
+	if _, exists := gameModes[name]; exists {
+		panic(fmt.Sprintf("game mode %q registered twice", name))
+	}
+	for itemType, code := range mode.ItemTypes() {
+		protocol.RegisterPowerUpType(itemType, code)
+	}
+	gameModes[name] = mode
+}
+
+func lookupMode(name string) (GameMode, bool) {
+	mode, ok := gameModes[name]
+	return mode, ok
+}
+
+func (r *room) mode() GameMode {
+	if mode, ok := gameModes[r.state.Mode]; ok {
+		return mode
+	}
+	return gameModes["classic"]
+}
+
+// topScorerLocked is the default winner: the player with the highest score.
+func (r *room) topScorerLocked() string {
+	var best *playerState
+	for _, id := range sortedPlayerIDs(r.players) {
+		p := r.players[id]
+		if best == nil || p.Score > best.Score {
+			best = p
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.ID
+}
+
+func (r *room) clearModeEntitiesLocked() {
+	r.state.Fish = fishState{Size: fishSize, Alive: false, Type: "normal", Direction: 1}
+	r.state.PowerUp.Active = false
+	r.state.PowerUps = nil
+	r.state.Mines = nil
+}
+
+type classicMode struct{}
+
+func init() {
+	registerMode(classicMode{})
+	registerMode(bombPassMode{})
+	registerMode(hideSeekMode{})
+}
+
+func (classicMode) Name() string { return "classic" }
+
+func (classicMode) Defaults(settings *roomSettings) {}
+
+func (classicMode) WallParams() wallParams {
+	return wallParams{ThicknessRate: wallThicknessRate, MaxTotalLen: maxWallTotalLen, MaxSegments: maxSegments}
+}
+
+func (classicMode) ItemTypes() map[string]uint8 {
+	return map[string]uint8{
+		"fast":         1,
+		"slow":         2,
+		"invert":       3,
+		"shield":       19,
+		"magnet":       20,
+		"ghost":        21,
+		"timeIncrease": 22,
+		"timeDecrease": 23,
+	}
+}
+
+func (classicMode) StartRound(r *room) {
+	if m := r.activeMapLocked(); m != nil {
+		r.applyMapWallsLocked(m)
+	}
+	r.spawnFishLocked()
+}
+
+func (classicMode) Tick(r *room) {
+	r.state.Remaining -= tickRate.Seconds()
+	r.updateGoldenChainLocked()
+	r.updatePowerUpLocked()
+	r.updatePlayersLocked()
+	if r.countAlivePlayersLocked() == 0 {
+		r.endRoundLocked("Раунд завершён: все коты погибли")
+		return
+	}
+	r.updateFishLocked()
+	if r.state.Remaining <= 0 {
+		r.endRoundLocked("Раунд завершён")
+	}
+}
+
+func (classicMode) Pickup(r *room, p *playerState) {
+	if !r.state.PowerUp.Active {
+		return
+	}
+	dist := math.Hypot(p.X-r.state.PowerUp.X, p.Y-r.state.PowerUp.Y)
+	if dist < (p.Size+r.state.PowerUp.Size)/2 {
+		r.state.PowerUp.Active = false
+		r.state.PowerUp.Remaining = 0
+		r.applyPowerUpLocked(p, r.state.PowerUp.Type)
+		r.emitEventLocked(gameEvent{Type: "powerUpCollected", PlayerID: p.ID})
+	}
+}
+
+func (classicMode) Collide(r *room) {}
+
+func (classicMode) Winner(r *room) string {
+	return r.topScorerLocked()
+}
+
+func (classicMode) MatchRule() matchRule { return matchRule{Min: 2, Ideal: 4, Max: 6} }
+
+func (classicMode) Teams(settings roomSettings) teamRule { return teamRule{} }
+
+func (classicMode) Ranking(r *room) [][]string { return r.scoreRankingLocked() }
+
+type bombPassMode struct{}
+
+func (bombPassMode) Name() string { return "bomb-pass" }
+
+func (bombPassMode) Defaults(settings *roomSettings) {
+	settings.WorldScale = bombWorldScale
+}
+
+func (bombPassMode) WallParams() wallParams { return arenaWallParams }
+
+func (bombPassMode) ItemTypes() map[string]uint8 {
+	return map[string]uint8{"fast": 1, "slow": 2, "invert": 3, "ghost": 21}
+}
+
+func (bombPassMode) StartRound(r *room) {
+	r.clearModeEntitiesLocked()
+	r.buildArenaWithWallsLocked()
+	r.bombPowerUpTimer = 0
+	r.hauntImmunity = make(map[string]float64)
+	r.updateBombPowerUpLocked()
+	r.fillBombsLocked()
+}
+
+func (bombPassMode) Tick(r *room) {
+	r.updatePlayersLocked()
+	r.updateGhostsLocked()
+	r.tickAbilityCooldownsLocked()
+	r.updateBombPowerUpLocked()
+	r.updateBombPassLocked()
+}
+
+func (bombPassMode) Pickup(r *room, p *playerState) {
+	r.collectBombPowerUpsLocked(p)
+}
+
+func (bombPassMode) Collide(r *room) {
+	r.resolvePlayerCollisionsLocked()
+}
+
+func (bombPassMode) Winner(r *room) string {
+	for _, id := range sortedPlayerIDs(r.players) {
+		if r.players[id].Alive {
+			return id
+		}
+	}
+	return ""
+}
+
+func (bombPassMode) MatchRule() matchRule { return matchRule{Min: 3, Ideal: 8, Max: 20} }
+
+// Teams are optional in bomb-pass; a team scores by the cats it keeps alive.
+func (bombPassMode) Teams(settings roomSettings) teamRule {
+	return teamRule{Count: settings.Teams, Survival: true}
+}
+
+func (bombPassMode) Ranking(r *room) [][]string { return r.survivalRankingLocked() }
+
+type hideSeekMode struct{}
+
+func (hideSeekMode) Name() string { return "hide-and-seek" }
+
+func (hideSeekMode) Defaults(settings *roomSettings) {
+	settings.WorldScale = hideSeekWorldScale
+}
+
+func (hideSeekMode) WallParams() wallParams { return arenaWallParams }
+
+func (hideSeekMode) ItemTypes() map[string]uint8 {
+	return map[string]uint8{
+		"memory":   4,
+		"chair":    5,
+		"table":    6,
+		"fish":     7,
+		"duck":     8,
+		"goose":    9,
+		"goldfish": 10,
+		"mine":     11,
+		"alarm":    12,
+	}
+}
+
+func (hideSeekMode) StartRound(r *room) {
+	r.clearModeEntitiesLocked()
+	r.state.Remaining = r.state.Settings.HideDuration
+	r.state.HidePhase = "hiding"
+	r.state.SeekerIDs = r.pickSeekersLocked()
+	r.startHideSeekHidersLocked()
+	r.placeSeekersAwayLocked()
+	names := make([]string, 0, len(r.state.SeekerIDs))
+	for _, id := range r.state.SeekerIDs {
+		names = append(names, fallbackName(r.players[id].Name))
+	}
+	seekerNames := "случайный котик"
+	if len(names) > 0 {
+		seekerNames = strings.Join(names, ", ")
+	}
+	label := "Ведущий"
+	if len(names) > 1 {
+		label = "Ведущие"
+	}
+	r.state.Message = fmt.Sprintf("%s: %s. У вас %s, чтобы спрятаться!", label, seekerNames, formatSecondsRu(r.state.Settings.HideDuration))
+	r.buildArenaWithWallsLocked()
+	r.state.PowerUps = r.spawnHideAndSeekItemsLocked()
+}
+
+func (hideSeekMode) Tick(r *room) {
+	r.state.Remaining -= tickRate.Seconds()
+	r.updatePlayersLocked()
+	r.tickAbilityCooldownsLocked()
+	r.updatePingsLocked()
+	if r.endIfSideWipedOutLocked() {
+		return
+	}
+	if r.state.HidePhase == "hiding" {
+		if r.state.Remaining <= 0 {
+			r.startHideSeekSearchPhaseLocked()
+		}
+		return
+	}
+	r.trackHiddenTimeLocked()
+	r.handleHideSeekCapturesLocked()
+	if r.state.Phase == "playing" && r.state.Remaining <= 0 {
+		r.state.WinnerID = r.pickAliveHiderLocked()
+		if r.state.WinnerID == "" {
+			r.state.WinnerID = r.bestSeekerLocked()
+		}
+		r.endRoundLocked("Время на поиск закончилось")
+	}
+}
+
+func (hideSeekMode) Pickup(r *room, p *playerState) {
+	r.handleHideSeekDisguiseLocked(p)
+}
+
+func (hideSeekMode) Collide(r *room) {}
+
+func (hideSeekMode) Winner(r *room) string {
+	if r.state.WinnerID != "" {
+		return r.state.WinnerID
+	}
+	if r.state.HidePhase == "seeking" {
+		if hider := r.pickAliveHiderLocked(); hider != "" {
+			return hider
+		}
+		if seeker := r.bestSeekerLocked(); seeker != "" {
+			return seeker
+		}
+	}
+	return r.topScorerLocked()
+}
+
+func (hideSeekMode) MatchRule() matchRule { return matchRule{Min: 3, Ideal: 6, Max: 15} }
+
+func (hideSeekMode) Teams(settings roomSettings) teamRule { return teamRule{} }
+
+func (hideSeekMode) Ranking(r *room) [][]string { return r.hideSeekRankingLocked() }
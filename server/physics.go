@@ -0,0 +1,119 @@
+package main
+
+import "math"
+
+// contactSkin keeps a moved circle this far off the wall it stopped at, so
+// rounding never leaves it overlapping the wall on the next tick.
+const contactSkin = 0.01
+
+// sweepCircleRect finds when a circle moving from (x, y) by (dx, dy) first
+// touches the wall. It returns the fraction of the move made before the
+// contact and the wall normal there. Walls the circle already overlaps are
+// ignored; resolveEntityWallCollisions pushes the circle out of those.
+func sweepCircleRect(x, y, radius, dx, dy float64, w wall) (float64, vector, bool) {
+	if pointInsideRect(x, y, w) || circleIntersectsRect(x, y, radius, w) {
+		return 0, vector{}, false
+	}
+	// The circle centre touches the wall exactly when it enters the wall
+	// grown by the radius, with rounded corners.
+	grown := wall{X: w.X - radius, Y: w.Y - radius, Width: w.Width + radius*2, Height: w.Height + radius*2}
+	hit, t := lineIntersectsRect(x, y, x+dx, y+dy, grown)
+	if !hit {
+		return 0, vector{}, false
+	}
+	px, py := x+dx*t, y+dy*t
+	cornerX := clampFloat(px, w.X, w.X+w.Width)
+	cornerY := clampFloat(py, w.Y, w.Y+w.Height)
+	var normal vector
+	if cornerX != px && cornerY != py {
+		// Entered the grown box at a corner: test the rounded corner itself.
+		s, ok := segmentCircleEntry(x, y, dx, dy, cornerX, cornerY, radius)
+		if !ok {
+			return 0, vector{}, false
+		}
+		t = s
+		normal = vector{X: (x + dx*t - cornerX) / radius, Y: (y + dy*t - cornerY) / radius}
+	} else {
+		// The side the centre is closest to is the one it crossed.
+		sides := []struct {
+			dist   float64
+			normal vector
+		}{
+			{math.Abs(px - grown.X), vector{X: -1}},
+			{math.Abs(grown.X + grown.Width - px), vector{X: 1}},
+			{math.Abs(py - grown.Y), vector{Y: -1}},
+			{math.Abs(grown.Y + grown.Height - py), vector{Y: 1}},
+		}
+		best := sides[0]
+		for _, side := range sides[1:] {
+			if side.dist < best.dist {
+				best = side
+			}
+		}
+		normal = best.normal
+	}
+	// A circle resting against the wall may always move away from it.
+	if dx*normal.X+dy*normal.Y >= 0 {
+		return 0, vector{}, false
+	}
+	return t, normal, true
+}
+
+// segmentCircleEntry returns the fraction of the move (dx, dy) from (x, y)
+// at which the point enters the circle.
+func segmentCircleEntry(x, y, dx, dy, cx, cy, radius float64) (float64, bool) {
+	a := dx*dx + dy*dy
+	if a == 0 {
+		return 0, false
+	}
+	ox, oy := x-cx, y-cy
+	b := ox*dx + oy*dy
+	c := ox*ox + oy*oy - radius*radius
+	disc := b*b - a*c
+	if disc < 0 {
+		return 0, false
+	}
+	s := (-b - math.Sqrt(disc)) / a
+	if s < 0 || s > 1 {
+		return 0, false
+	}
+	return s, true
+}
+
+// sweepCircle finds the first wall a moving circle touches. A zero radius
+// traces a ray, which is how shots are checked.
+func sweepCircle(x, y, radius, dx, dy float64, walls []wall) (float64, vector, bool) {
+	best, normal, hit := 1.0, vector{}, false
+	for _, w := range walls {
+		if t, n, ok := sweepCircleRect(x, y, radius, dx, dy, w); ok && (!hit || t < best) {
+			best, normal, hit = t, n, true
+		}
+	}
+	return best, normal, hit
+}
+
+// moveCircle moves a circle by (dx, dy) without letting it pass through a
+// wall, however fast it goes or however thin the wall is. On contact the
+// rest of the move slides along the wall.
+func moveCircle(x, y, radius, dx, dy float64, walls []wall) (float64, float64, bool) {
+	touched := false
+	for i := 0; i < 3 && (dx != 0 || dy != 0); i++ {
+		t, normal, hit := sweepCircle(x, y, radius, dx, dy, walls)
+		if !hit {
+			return x + dx, y + dy, touched
+		}
+		touched = true
+		length := math.Hypot(dx, dy)
+		step := math.Max(t-contactSkin/length, 0)
+		x += dx * step
+		y += dy * step
+		dx *= 1 - step
+		dy *= 1 - step
+		into := dx*normal.X + dy*normal.Y
+		if into < 0 {
+			dx -= into * normal.X
+			dy -= into * normal.Y
+		}
+	}
+	return x, y, touched
+}
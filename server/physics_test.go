@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const physicsTolerance = 1e-6
+
+func TestSweepCircleRect(t *testing.T) {
+	box := wall{X: 50, Y: 0, Width: 10, Height: 100}
+	square := wall{X: 0, Y: 0, Width: 10, Height: 10}
+	thin := wall{X: 50, Y: 0, Width: 1, Height: 100}
+	diagonal := 1 / math.Sqrt2
+
+	tests := []struct {
+		name       string
+		x, y       float64
+		radius     float64
+		dx, dy     float64
+		w          wall
+		wantHit    bool
+		wantT      float64
+		wantNormal vector
+	}{
+		{name: "head-on", x: 0, y: 50, radius: 10, dx: 100, w: box, wantHit: true, wantT: 0.4, wantNormal: vector{X: -1}},
+		{name: "head-on from below", x: 20, y: 150, radius: 10, dy: -100, w: wall{X: 0, Y: 0, Width: 100, Height: 100}, wantHit: true, wantT: 0.4, wantNormal: vector{Y: 1}},
+		{name: "stops short", x: 0, y: 50, radius: 10, dx: 30, w: box},
+		{name: "passes beside", x: -50, y: 30, radius: 10, dx: 200, w: wall{X: 0, Y: 0, Width: 100, Height: 10}},
+		{name: "rounded corner", x: 30, y: 30, radius: 5, dx: -20, dy: -20, w: square, wantHit: true, wantT: (30 - 10 - 5*diagonal) / 20, wantNormal: vector{X: diagonal, Y: diagonal}},
+		{name: "clips grown corner only", x: 20, y: 8, radius: 5, dx: -10, dy: 10, w: square},
+		{name: "resting contact moving in", x: 40, y: 50, radius: 10, dx: 5, w: box, wantHit: true, wantT: 0, wantNormal: vector{X: -1}},
+		{name: "resting contact moving away", x: 40, y: 50, radius: 10, dx: -5, w: box},
+		{name: "resting contact moving along", x: 40, y: 50, radius: 10, dy: 20, w: box},
+		{name: "already overlapping", x: 45, y: 50, radius: 10, dx: 5, w: box},
+		{name: "thin wall at speed", x: 0, y: 50, radius: 5, dx: 1000, w: thin, wantHit: true, wantT: 0.045, wantNormal: vector{X: -1}},
+		{name: "ray through thin wall", x: 0, y: 50, dx: 1000, w: thin, wantHit: true, wantT: 0.05, wantNormal: vector{X: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotT, gotNormal, hit := sweepCircleRect(tt.x, tt.y, tt.radius, tt.dx, tt.dy, tt.w)
+			if hit != tt.wantHit {
+				t.Fatalf("hit = %v, want %v", hit, tt.wantHit)
+			}
+			if !hit {
+				return
+			}
+			if math.Abs(gotT-tt.wantT) > physicsTolerance {
+				t.Errorf("t = %v, want %v", gotT, tt.wantT)
+			}
+			if math.Abs(gotNormal.X-tt.wantNormal.X) > physicsTolerance || math.Abs(gotNormal.Y-tt.wantNormal.Y) > physicsTolerance {
+				t.Errorf("normal = %+v, want %+v", gotNormal, tt.wantNormal)
+			}
+		})
+	}
+}
+
+func TestMoveCircle(t *testing.T) {
+	tall := wall{X: 50, Y: 0, Width: 10, Height: 200}
+	tests := []struct {
+		name        string
+		x, y        float64
+		radius      float64
+		dx, dy      float64
+		walls       []wall
+		wantX       float64
+		wantY       float64
+		wantTouched bool
+	}{
+		{name: "free move", x: 0, y: 0, radius: 10, dx: 30, dy: -20, wantX: 30, wantY: -20},
+		{name: "stops at wall", x: 0, y: 50, radius: 10, dx: 100, walls: []wall{tall}, wantX: 40 - contactSkin, wantY: 50, wantTouched: true},
+		{name: "no tunnelling through thin wall", x: 0, y: 50, radius: 5, dx: 1000, walls: []wall{{X: 50, Y: 0, Width: 1, Height: 100}}, wantX: 45 - contactSkin, wantY: 50, wantTouched: true},
+		{name: "slides along wall", x: 30, y: 50, radius: 10, dx: 30, dy: 30, walls: []wall{tall}, wantX: 40, wantY: 80, wantTouched: true},
+		{name: "resting contact moving along", x: 40, y: 50, radius: 10, dy: 30, walls: []wall{tall}, wantX: 40, wantY: 80},
+		{name: "resting contact moving away", x: 40, y: 50, radius: 10, dx: -30, walls: []wall{tall}, wantX: 10, wantY: 50},
+		{name: "wedged into inner corner", x: 20, y: 20, radius: 5, dx: 100, dy: 100, walls: []wall{{X: 50, Y: 0, Width: 10, Height: 60}, {X: 0, Y: 50, Width: 60, Height: 10}}, wantX: 45, wantY: 45, wantTouched: true},
+		{name: "passes outer corner", x: 30, y: 30, radius: 5, dx: -20, dy: -10, walls: []wall{{X: 0, Y: 0, Width: 10, Height: 10}}, wantX: 10, wantY: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, touched := moveCircle(tt.x, tt.y, tt.radius, tt.dx, tt.dy, tt.walls)
+			if touched != tt.wantTouched {
+				t.Errorf("touched = %v, want %v", touched, tt.wantTouched)
+			}
+			// Sliding keeps a contact skin off each wall it met.
+			if math.Abs(x-tt.wantX) > 0.05 || math.Abs(y-tt.wantY) > 0.05 {
+				t.Errorf("moved to (%v, %v), want (%v, %v)", x, y, tt.wantX, tt.wantY)
+			}
+			for _, w := range tt.walls {
+				if circleIntersectsRect(x, y, tt.radius, w) {
+					t.Errorf("ends overlapping wall %+v at (%v, %v)", w, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestFishCollidesAtWallItOverlaps(t *testing.T) {
+	r := &room{state: gameState{
+		Settings: roomSettings{WorldScale: 1},
+		Fish:     fishState{X: 100, Y: 100, Size: 20},
+		Walls:    []wall{{X: 95, Y: 0, Width: 10, Height: 200}},
+	}}
+	if !r.fishCollidesAt(103) {
+		t.Error("fish may swim further into a wall it already overlaps")
+	}
+	if r.fishCollidesAt(200) {
+		t.Error("fish is blocked in open water")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "math"
+
+// powerUpEffects maps the power-up lying on the ground to the status effect
+// it gives on pickup.
+var powerUpEffects = map[string]string{
+	"fast":         "speedUp",
+	"slow":         "speedDown",
+	"invert":       "invert",
+	"shield":       "shield",
+	"magnet":       "magnet",
+	"ghost":        "ghost",
+	"timeIncrease": "timeIncrease",
+	"timeDecrease": "timeDecrease",
+}
+
+var (
+	classicPowerUpTypes = []string{"fast", "slow", "invert", "shield", "magnet", "ghost", "timeIncrease", "timeDecrease"}
+	bombPowerUpTypes    = []string{"fast", "slow", "invert", "ghost"}
+)
+
+func (r *room) applyPowerUpLocked(p *playerState, powerUpType string) {
+	effectType, ok := powerUpEffects[powerUpType]
+	if !ok {
+		return
+	}
+	addStatusEffect(p, effectType)
+	switch effectType {
+	case "timeIncrease":
+		r.state.Remaining = math.Max(r.state.Remaining, timeIncreaseLimit)
+	case "timeDecrease":
+		r.state.Remaining = math.Min(r.state.Remaining, timeDecreaseLimit)
+	}
+}
+
+func hasEffect(p *playerState, effectType string) bool {
+	for _, effect := range p.Effects {
+		if effect.Type == effectType {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEffect drops an effect early, e.g. a shield that absorbed a mine,
+// and reports whether the player had it.
+func removeEffect(p *playerState, effectType string) bool {
+	for i, effect := range p.Effects {
+		if effect.Type == effectType {
+			p.Effects = append(p.Effects[:i:i], p.Effects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// pullFishWithMagnetLocked drags the fish towards the closest cat holding a
+// magnet, sliding along walls.
+func (r *room) pullFishWithMagnetLocked() {
+	fish := &r.state.Fish
+	var target *playerState
+	best := math.Inf(1)
+	for _, id := range sortedPlayerIDs(r.players) {
+		p := r.players[id]
+		if !p.Alive || !hasEffect(p, "magnet") {
+			continue
+		}
+		if dist := math.Hypot(p.X-fish.X, p.Y-fish.Y); dist < best {
+			best = dist
+			target = p
+		}
+	}
+	if target == nil || best < 1 {
+		return
+	}
+	step := math.Min(magnetPullSpeed*tickRate.Seconds(), best)
+	fish.X, fish.Y, _ = moveCircle(fish.X, fish.Y, fish.Size/2, (target.X-fish.X)/best*step, (target.Y-fish.Y)/best*step, r.state.Walls)
+}
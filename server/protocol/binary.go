@@ -8,27 +8,11 @@ import (
 )
 
 var (
-	phaseCodes       = map[string]uint8{"lobby": 0, "countdown": 1, "playing": 2, "ended": 3}
-	fishTypeCodes    = map[string]uint8{"normal": 0, "golden": 1, "timeIncrease": 2, "timeDecrease": 3}
-	powerUpTypeCodes = map[string]uint8{
-		"none":     0,
-		"fast":     1,
-		"slow":     2,
-		"invert":   3,
-		"memory":   4,
-		"chair":    5,
-		"table":    6,
-		"fish":     7,
-		"duck":     8,
-		"goose":    9,
-		"goldfish": 10,
-		"mine":     11,
-		"alarm":    12,
-		"blaster":  13,
-		"laser":    14,
-		"pistol":   15,
-		"plasma":   16,
-	}
+	phaseCodes    = map[string]uint8{"lobby": 0, "countdown": 1, "playing": 2, "ended": 3}
+	fishTypeCodes = map[string]uint8{"normal": 0, "golden": 1, "timeIncrease": 2, "timeDecrease": 3}
+	// powerUpTypeCodes is filled by the game modes through
+	// RegisterPowerUpType.
+	powerUpTypeCodes = map[string]uint8{"none": 0}
 
 	messageTypeFull  uint8 = 0
 	messageTypePatch uint8 = 1
@@ -147,21 +131,27 @@ func (r *binaryReader) readFloatVector() (*Vector, error) {
 	return &Vector{X: float64(x), Y: float64(y)}, nil
 }
 
-func DecodeInputBuffer(data []byte) (*string, *Vector, *bool) {
+// DecodeInputBuffer reads a client input frame. The trailing aim vector is
+// optional so that older clients keep working.
+func DecodeInputBuffer(data []byte) (*string, *Vector, *bool, *Vector) {
 	reader := &binaryReader{data: data}
 	playerID, err := reader.readString()
 	if err != nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 	vec, err := reader.readFloatVector()
 	if err != nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 	shoot, err := reader.readBool()
 	if err != nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
+	}
+	aim, err := reader.readFloatVector()
+	if err != nil {
+		return &playerID, vec, &shoot, nil
 	}
-	return &playerID, vec, &shoot
+	return &playerID, vec, &shoot, aim
 }
 
 func encodeWallsBinary(walls []Wall, writer *binaryWriter) {
@@ -178,6 +168,20 @@ func encodeWallsBinary(walls []Wall, writer *binaryWriter) {
 	}
 }
 
+// RegisterPowerUpType assigns a binary code to a power-up type. Several modes
+// may share a type as long as they agree on its code.
+func RegisterPowerUpType(name string, code uint8) {
+	for existing, existingCode := range powerUpTypeCodes {
+		if existing == name && existingCode != code {
+			panic(fmt.Sprintf("power-up type %q already has code %d", name, existingCode))
+		}
+		if existing != name && existingCode == code {
+			panic(fmt.Sprintf("power-up code %d already used by %q", code, existing))
+		}
+	}
+	powerUpTypeCodes[name] = code
+}
+
 func encodePowerUpsBinary(powerUps []PowerUpState, writer *binaryWriter) {
 	count := len(powerUps)
 	if count > 255 {
@@ -207,6 +211,34 @@ func encodeMinesBinary(mines []Mine, writer *binaryWriter) {
 	}
 }
 
+func encodeSettingsBinary(settings RoomSettings, writer *binaryWriter) {
+	writer.writeFloat32(float32(settings.RoundDuration))
+	writer.writeFloat32(float32(settings.ShooterRoundDuration))
+	writer.writeFloat32(float32(settings.ShooterPrepDuration))
+	writer.writeFloat32(float32(settings.BombTimerDuration))
+	writer.writeFloat32(float32(settings.BombTimerBonus))
+	writer.writeUint16(uint16(settings.ShooterDamage))
+	writer.writeUint16(uint16(settings.ShooterMaxHealth))
+	writer.writeFloat32(float32(settings.HideDuration))
+	writer.writeFloat32(float32(settings.SeekDuration))
+	writer.writeUint8(uint8(settings.MaxMines))
+	writer.writeFloat32(float32(settings.WorldScale))
+	writer.writeUint8(uint8(settings.FishPoints))
+	writer.writeUint8(uint8(settings.GoldenFishPoints))
+	writer.writeFloat32(float32(settings.GoldenFishChance))
+	writer.writeFloat32(float32(settings.TimeFishChance))
+	writer.writeFloat32(float32(settings.TimeFishSeconds))
+	writer.writeFloat32(float32(settings.GoldenChainDuration))
+	writer.writeUint8(uint8(settings.Teams))
+	writer.writeBool(settings.FriendlyFire)
+	writer.writeBool(settings.AutoBalance)
+	writer.writeBool(settings.Infection)
+	writer.writeFloat32(float32(settings.BombBlastRadius))
+	writer.writeBool(settings.HotPotato)
+	writer.writeFloat32(float32(settings.ArenaEventInterval))
+	writer.writeString(settings.Map)
+}
+
 func encodePlayersBinary(players []PlayerState, writer *binaryWriter) {
 	count := len(players)
 	if count > 32 {
@@ -236,9 +268,129 @@ func encodePlayersBinary(players []PlayerState, writer *binaryWriter) {
 		}
 		writer.writeString(appearance)
 		writer.writeString(p.Disguise)
+		writer.writeUint16(uint16(p.Ammo))
+		writer.writeFloat32(float32(p.Cooldown))
+		writer.writeUint16(uint16(p.Charges))
+		writer.writeFloat32(float32(p.AbilityCD))
+		writer.writeUint16(uint16(p.Armor))
+		writer.writeUint8(uint8(p.Team))
+		encodeEffectsBinary(p.Effects, writer)
+	}
+}
+
+func encodeEffectsBinary(effects []StatusEffect, writer *binaryWriter) {
+	count := len(effects)
+	if count > 8 {
+		count = 8
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeString(effects[i].Type)
+		writer.writeFloat32(float32(effects[i].Remaining))
+		writer.writeUint8(uint8(effects[i].Stacks))
+	}
+}
+
+func encodeBasesBinary(bases []TeamBase, writer *binaryWriter) {
+	count := len(bases)
+	if count > 8 {
+		count = 8
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeUint8(uint8(bases[i].Team))
+		writer.writeFloat32(float32(bases[i].X))
+		writer.writeFloat32(float32(bases[i].Y))
+		writer.writeFloat32(float32(bases[i].Radius))
+	}
+}
+
+func encodeStringsBinary(values []string, writer *binaryWriter) {
+	count := len(values)
+	if count > math.MaxUint16 {
+		count = math.MaxUint16
+	}
+	writer.writeUint16(uint16(count))
+	for i := 0; i < count; i++ {
+		writer.writeString(values[i])
+	}
+}
+
+func encodePingsBinary(pings []SoundPing, writer *binaryWriter) {
+	count := len(pings)
+	if count > 16 {
+		count = 16
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeFloat32(float32(pings[i].X))
+		writer.writeFloat32(float32(pings[i].Y))
+		writer.writeFloat32(float32(pings[i].Remaining))
+	}
+}
+
+func encodeHazardsBinary(hazards []HazardCell, writer *binaryWriter) {
+	count := len(hazards)
+	if count > 32 {
+		count = 32
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeUint8(uint8(hazards[i].Row))
+		writer.writeUint8(uint8(hazards[i].Col))
+		writer.writeFloat32(float32(hazards[i].X))
+		writer.writeFloat32(float32(hazards[i].Y))
+		writer.writeFloat32(float32(hazards[i].Size))
+		writer.writeFloat32(float32(hazards[i].Warning))
+		writer.writeFloat32(float32(hazards[i].Remaining))
+	}
+}
+
+func encodeBombsBinary(bombs []Bomb, writer *binaryWriter) {
+	count := len(bombs)
+	if count > 16 {
+		count = 16
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeString(bombs[i].Holder)
+		writer.writeFloat32(float32(bombs[i].Timer))
+		passes := bombs[i].Passes
+		if passes > 255 {
+			passes = 255
+		}
+		writer.writeUint8(uint8(passes))
+	}
+}
+
+func encodeHillsBinary(hills []HillZone, writer *binaryWriter) {
+	count := len(hills)
+	if count > 16 {
+		count = 16
+	}
+	writer.writeUint8(uint8(count))
+	for i := 0; i < count; i++ {
+		writer.writeUint8(uint8(hills[i].Row))
+		writer.writeUint8(uint8(hills[i].Col))
+		writer.writeFloat32(float32(hills[i].X))
+		writer.writeFloat32(float32(hills[i].Y))
+		writer.writeFloat32(float32(hills[i].Size))
+		writer.writeString(hills[i].Owner)
+		writer.writeBool(hills[i].Contested)
 	}
 }
 
+// encodeZoneBinary writes a zero radius when there is no safe zone.
+func encodeZoneBinary(zone *SafeZone, writer *binaryWriter) {
+	if zone == nil {
+		zone = &SafeZone{}
+	}
+	writer.writeFloat32(float32(zone.X))
+	writer.writeFloat32(float32(zone.Y))
+	writer.writeFloat32(float32(zone.Radius))
+	writer.writeFloat32(float32(zone.TargetRadius))
+}
+
 func EncodeState(state GameState) []byte {
 	writer := &binaryWriter{}
 	writer.writeUint8(messageTypeFull)
@@ -254,21 +406,8 @@ func EncodeState(state GameState) []byte {
 	writer.writeBool(state.Golden)
 	writer.writeString(state.WinnerID)
 	writer.writeString(state.Message)
-	writer.writeString(state.SeekerID)
-	writer.writeString(state.BombHolder)
-	writer.writeFloat32(float32(state.BombTimer))
-
-	statusType := ""
-	statusRemaining := float32(0)
-	statusPlayer := ""
-	if state.Status != nil {
-		statusType = state.Status.Type
-		statusRemaining = float32(state.Status.Remaining)
-		statusPlayer = state.Status.PlayerID
-	}
-	writer.writeString(statusType)
-	writer.writeFloat32(statusRemaining)
-	writer.writeString(statusPlayer)
+	encodeStringsBinary(state.SeekerIDs, writer)
+	encodeBombsBinary(state.Bombs, writer)
 
 	writer.writeUint8(fishTypeCodes[state.Fish.Type])
 	writer.writeFloat32(float32(state.Fish.X))
@@ -290,6 +429,15 @@ func EncodeState(state GameState) []byte {
 	encodeWallsBinary(state.Walls, writer)
 	encodeMinesBinary(state.Mines, writer)
 	encodePlayersBinary(state.Players, writer)
+	writer.writeString(state.HostID)
+	encodeSettingsBinary(state.Settings, writer)
+	encodeZoneBinary(state.Zone, writer)
+	writer.writeUint8(uint8(state.WinnerTeam))
+	encodeBasesBinary(state.Bases, writer)
+	writer.writeString(state.FishCarrier)
+	encodeHillsBinary(state.Hills, writer)
+	encodePingsBinary(state.Pings, writer)
+	encodeHazardsBinary(state.Hazards, writer)
 	return writer.bytes()
 }
 
@@ -297,6 +445,7 @@ func encodePlayerPatchBinary(p PlayerPatch, writer *binaryWriter) {
 	writer.writeString(p.ID)
 	var flags1 uint8
 	var flags2 uint8
+	var flags3 uint8
 	if p.Name != nil {
 		flags1 |= 1 << 0
 	}
@@ -342,9 +491,31 @@ func encodePlayerPatchBinary(p PlayerPatch, writer *binaryWriter) {
 	if p.Weapon != nil {
 		flags2 |= 1 << 6
 	}
+	if p.Ammo != nil {
+		flags3 |= 1 << 0
+	}
+	if p.Cooldown != nil {
+		flags3 |= 1 << 1
+	}
+	if p.Armor != nil {
+		flags3 |= 1 << 2
+	}
+	if p.Team != nil {
+		flags3 |= 1 << 3
+	}
+	if p.Effects != nil {
+		flags3 |= 1 << 4
+	}
+	if p.Charges != nil {
+		flags3 |= 1 << 5
+	}
+	if p.AbilityCD != nil {
+		flags3 |= 1 << 6
+	}
 
 	writer.writeUint8(flags1)
 	writer.writeUint8(flags2)
+	writer.writeUint8(flags3)
 
 	if p.Name != nil {
 		writer.writeString(*p.Name)
@@ -395,6 +566,27 @@ func encodePlayerPatchBinary(p PlayerPatch, writer *binaryWriter) {
 	if p.Weapon != nil {
 		writer.writeString(*p.Weapon)
 	}
+	if p.Ammo != nil {
+		writer.writeUint16(uint16(*p.Ammo))
+	}
+	if p.Cooldown != nil {
+		writer.writeFloat32(float32(*p.Cooldown))
+	}
+	if p.Armor != nil {
+		writer.writeUint16(uint16(*p.Armor))
+	}
+	if p.Team != nil {
+		writer.writeUint8(uint8(*p.Team))
+	}
+	if p.Effects != nil {
+		encodeEffectsBinary(p.Effects, writer)
+	}
+	if p.Charges != nil {
+		writer.writeUint16(uint16(*p.Charges))
+	}
+	if p.AbilityCD != nil {
+		writer.writeFloat32(float32(*p.AbilityCD))
+	}
 }
 
 func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
@@ -406,6 +598,7 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	var flags1 uint8
 	var flags2 uint8
 	var flags3 uint8
+	var flags4 uint8
 
 	if patch.Phase != nil {
 		flags1 |= 1 << 0
@@ -425,9 +618,7 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.Golden != nil {
 		flags1 |= 1 << 5
 	}
-	if patch.Status != nil {
-		flags1 |= 1 << 6
-	}
+	// Bit 6 of flags1 used to carry the room-wide status effect.
 	if patch.Fish != nil {
 		flags1 |= 1 << 7
 	}
@@ -450,17 +641,15 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.Mode != nil {
 		flags2 |= 1 << 5
 	}
-	if patch.BombHolder != nil {
+	if patch.Bombs != nil {
 		flags2 |= 1 << 6
 	}
-	if patch.BombTimer != nil {
-		flags2 |= 1 << 7
-	}
+	// Bit 7 of flags2 used to carry the single bomb timer.
 
 	if patch.PowerUps != nil {
 		flags3 |= 1 << 0
 	}
-	if patch.SeekerID != nil {
+	if patch.SeekerIDs != nil {
 		flags3 |= 1 << 1
 	}
 	if patch.HidePhase != nil {
@@ -469,10 +658,39 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.ShootPhase != nil {
 		flags3 |= 1 << 3
 	}
+	if patch.HostID != nil {
+		flags3 |= 1 << 4
+	}
+	if patch.Settings != nil {
+		flags3 |= 1 << 5
+	}
+	if patch.Zone != nil {
+		flags3 |= 1 << 6
+	}
+	if patch.WinnerTeam != nil {
+		flags3 |= 1 << 7
+	}
+
+	if patch.Bases != nil {
+		flags4 |= 1 << 0
+	}
+	if patch.FishCarrier != nil {
+		flags4 |= 1 << 1
+	}
+	if patch.Hills != nil {
+		flags4 |= 1 << 2
+	}
+	if patch.Pings != nil {
+		flags4 |= 1 << 3
+	}
+	if patch.Hazards != nil {
+		flags4 |= 1 << 4
+	}
 
 	writer.writeUint8(flags1)
 	writer.writeUint8(flags2)
 	writer.writeUint8(flags3)
+	writer.writeUint8(flags4)
 
 	if patch.Phase != nil {
 		writer.writeString(*patch.Phase)
@@ -492,11 +710,6 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.Golden != nil {
 		writer.writeBool(*patch.Golden)
 	}
-	if patch.Status != nil {
-		writer.writeString(patch.Status.Type)
-		writer.writeFloat32(float32(patch.Status.Remaining))
-		writer.writeString(patch.Status.PlayerID)
-	}
 	if patch.Fish != nil {
 		writer.writeUint8(fishTypeCodes[patch.Fish.Type])
 		writer.writeFloat32(float32(patch.Fish.X))
@@ -517,8 +730,8 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.PowerUps != nil {
 		encodePowerUpsBinary(patch.PowerUps, writer)
 	}
-	if patch.SeekerID != nil {
-		writer.writeString(*patch.SeekerID)
+	if patch.SeekerIDs != nil {
+		encodeStringsBinary(patch.SeekerIDs, writer)
 	}
 	if patch.HidePhase != nil {
 		writer.writeString(*patch.HidePhase)
@@ -556,11 +769,35 @@ func EncodePatch(patch *StatePatch, serverTime int64, tickIndex uint32) []byte {
 	if patch.Mode != nil {
 		writer.writeString(*patch.Mode)
 	}
-	if patch.BombHolder != nil {
-		writer.writeString(*patch.BombHolder)
+	if patch.Bombs != nil {
+		encodeBombsBinary(patch.Bombs, writer)
+	}
+	if patch.HostID != nil {
+		writer.writeString(*patch.HostID)
+	}
+	if patch.Settings != nil {
+		encodeSettingsBinary(*patch.Settings, writer)
+	}
+	if patch.Zone != nil {
+		encodeZoneBinary(patch.Zone, writer)
+	}
+	if patch.WinnerTeam != nil {
+		writer.writeUint8(uint8(*patch.WinnerTeam))
+	}
+	if patch.Bases != nil {
+		encodeBasesBinary(patch.Bases, writer)
+	}
+	if patch.FishCarrier != nil {
+		writer.writeString(*patch.FishCarrier)
+	}
+	if patch.Hills != nil {
+		encodeHillsBinary(patch.Hills, writer)
+	}
+	if patch.Pings != nil {
+		encodePingsBinary(patch.Pings, writer)
 	}
-	if patch.BombTimer != nil {
-		writer.writeFloat32(float32(*patch.BombTimer))
+	if patch.Hazards != nil {
+		encodeHazardsBinary(patch.Hazards, writer)
 	}
 
 	return writer.bytes()
@@ -30,7 +30,81 @@ type PowerUpState struct {
 type StatusEffect struct {
 	Type      string  `json:"type"`
 	Remaining float64 `json:"remaining"`
-	PlayerID  string  `json:"playerId,omitempty"`
+	Stacks    int     `json:"stacks"`
+}
+
+type RoomSettings struct {
+	RoundDuration        float64 `json:"roundDuration"`
+	ShooterRoundDuration float64 `json:"shooterRoundDuration"`
+	ShooterPrepDuration  float64 `json:"shooterPrepDuration"`
+	BombTimerDuration    float64 `json:"bombTimerDuration"`
+	BombTimerBonus       float64 `json:"bombTimerBonus"`
+	ShooterDamage        int     `json:"shooterDamage"`
+	ShooterMaxHealth     int     `json:"shooterMaxHealth"`
+	HideDuration         float64 `json:"hideDuration"`
+	SeekDuration         float64 `json:"seekDuration"`
+	MaxMines             int     `json:"maxMines"`
+	WorldScale           float64 `json:"worldScale"`
+	FishPoints           int     `json:"fishPoints"`
+	GoldenFishPoints     int     `json:"goldenFishPoints"`
+	GoldenFishChance     float64 `json:"goldenFishChance"`
+	TimeFishChance       float64 `json:"timeFishChance"`
+	TimeFishSeconds      float64 `json:"timeFishSeconds"`
+	GoldenChainDuration  float64 `json:"goldenChainDuration"`
+	Teams                int     `json:"teams"`
+	FriendlyFire         bool    `json:"friendlyFire"`
+	AutoBalance          bool    `json:"autoBalance"`
+	Infection            bool    `json:"infection"`
+	BombBlastRadius      float64 `json:"bombBlastRadius"`
+	HotPotato            bool    `json:"hotPotato"`
+	ArenaEventInterval   float64 `json:"arenaEventInterval"`
+	Map                  string  `json:"map"`
+}
+
+type HazardCell struct {
+	Row       int     `json:"row"`
+	Col       int     `json:"col"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Size      float64 `json:"size"`
+	Warning   float64 `json:"warning"`
+	Remaining float64 `json:"remaining"`
+}
+
+type Bomb struct {
+	Holder string  `json:"holder"`
+	Timer  float64 `json:"timer"`
+	Passes int     `json:"passes"`
+}
+
+type SafeZone struct {
+	X            float64 `json:"x"`
+	Y            float64 `json:"y"`
+	Radius       float64 `json:"radius"`
+	TargetRadius float64 `json:"targetRadius"`
+}
+
+type TeamBase struct {
+	Team   int     `json:"team"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Radius float64 `json:"radius"`
+}
+
+type HillZone struct {
+	Row       int     `json:"row"`
+	Col       int     `json:"col"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Size      float64 `json:"size"`
+	Owner     string  `json:"owner,omitempty"`
+	Contested bool    `json:"contested,omitempty"`
+}
+
+type SoundPing struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Remaining float64 `json:"remaining"`
 }
 
 type FishState struct {
@@ -44,22 +118,29 @@ type FishState struct {
 }
 
 type PlayerState struct {
-	ID         string  `json:"id"`
-	Name       string  `json:"name"`
-	Ready      bool    `json:"ready"`
-	Alive      bool    `json:"alive"`
-	X          float64 `json:"x"`
-	Y          float64 `json:"y"`
-	Size       float64 `json:"size"`
-	Facing     int     `json:"facing"`
-	Moving     bool    `json:"moving"`
-	WalkCycle  float64 `json:"walkCycle"`
-	StepAccum  float64 `json:"stepAccumulator"`
-	Score      int     `json:"score"`
-	Health     int     `json:"health"`
-	Weapon     string  `json:"weapon"`
-	Appearance string  `json:"appearance"`
-	Disguise   string  `json:"disguise,omitempty"`
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Ready      bool           `json:"ready"`
+	Alive      bool           `json:"alive"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Size       float64        `json:"size"`
+	Facing     int            `json:"facing"`
+	Moving     bool           `json:"moving"`
+	WalkCycle  float64        `json:"walkCycle"`
+	StepAccum  float64        `json:"stepAccumulator"`
+	Score      int            `json:"score"`
+	Team       int            `json:"team"`
+	Health     int            `json:"health"`
+	Armor      int            `json:"armor"`
+	Weapon     string         `json:"weapon"`
+	Ammo       int            `json:"ammo"`
+	Cooldown   float64        `json:"cooldown"`
+	Charges    int            `json:"charges"`
+	AbilityCD  float64        `json:"abilityCooldown"`
+	Appearance string         `json:"appearance"`
+	Disguise   string         `json:"disguise,omitempty"`
+	Effects    []StatusEffect `json:"effects"`
 }
 
 type ShotEvent struct {
@@ -72,48 +153,62 @@ type ShotEvent struct {
 }
 
 type GameState struct {
-	RoomName   string         `json:"roomName"`
-	Mode       string         `json:"mode"`
-	Phase      string         `json:"phase"`
-	Countdown  float64        `json:"countdown"`
-	Remaining  float64        `json:"remaining"`
-	HidePhase  string         `json:"hidePhase"`
-	ShootPhase string         `json:"shootPhase"`
-	Message    string         `json:"message"`
-	SeekerID   string         `json:"seekerId"`
-	BombHolder string         `json:"bombHolder"`
-	BombTimer  float64        `json:"bombTimer"`
-	Players    []PlayerState  `json:"players"`
-	Fish       FishState      `json:"fish"`
-	Walls      []Wall         `json:"walls"`
-	Mines      []Mine         `json:"mines"`
-	PowerUp    PowerUpState   `json:"powerUp"`
-	PowerUps   []PowerUpState `json:"powerUps"`
-	Shots      []ShotEvent    `json:"shots"`
-	Status     *StatusEffect  `json:"statusEffect"`
-	WinnerID   string         `json:"winnerId"`
-	Golden     bool           `json:"goldenChainActive"`
-	TickIndex  uint32         `json:"tickIndex"`
-	ServerTime int64          `json:"serverTime"`
+	RoomName    string         `json:"roomName"`
+	Mode        string         `json:"mode"`
+	Phase       string         `json:"phase"`
+	Countdown   float64        `json:"countdown"`
+	Remaining   float64        `json:"remaining"`
+	HidePhase   string         `json:"hidePhase"`
+	ShootPhase  string         `json:"shootPhase"`
+	Message     string         `json:"message"`
+	SeekerIDs   []string       `json:"seekerIds"`
+	Bombs       []Bomb         `json:"bombs"`
+	Players     []PlayerState  `json:"players"`
+	Fish        FishState      `json:"fish"`
+	Walls       []Wall         `json:"walls"`
+	Mines       []Mine         `json:"mines"`
+	PowerUp     PowerUpState   `json:"powerUp"`
+	PowerUps    []PowerUpState `json:"powerUps"`
+	Shots       []ShotEvent    `json:"shots"`
+	Zone        *SafeZone      `json:"zone,omitempty"`
+	Bases       []TeamBase     `json:"bases"`
+	FishCarrier string         `json:"fishCarrier"`
+	Hills       []HillZone     `json:"hills"`
+	Pings       []SoundPing    `json:"pings"`
+	Hazards     []HazardCell   `json:"hazards"`
+	WinnerID    string         `json:"winnerId"`
+	WinnerTeam  int            `json:"winnerTeam"`
+	Golden      bool           `json:"goldenChainActive"`
+	HostID      string         `json:"hostId"`
+	Settings    RoomSettings   `json:"settings"`
+	TickIndex   uint32         `json:"tickIndex"`
+	ServerTime  int64          `json:"serverTime"`
 }
 
 type PlayerPatch struct {
-	ID         string   `json:"id"`
-	Name       *string  `json:"name,omitempty"`
-	Ready      *bool    `json:"ready,omitempty"`
-	Alive      *bool    `json:"alive,omitempty"`
-	X          *float64 `json:"x,omitempty"`
-	Y          *float64 `json:"y,omitempty"`
-	Size       *float64 `json:"size,omitempty"`
-	Facing     *int     `json:"facing,omitempty"`
-	Moving     *bool    `json:"moving,omitempty"`
-	WalkCycle  *float64 `json:"walkCycle,omitempty"`
-	StepAccum  *float64 `json:"stepAccumulator,omitempty"`
-	Score      *int     `json:"score,omitempty"`
-	Health     *int     `json:"health,omitempty"`
-	Weapon     *string  `json:"weapon,omitempty"`
-	Appearance *string  `json:"appearance,omitempty"`
-	Disguise   *string  `json:"disguise,omitempty"`
+	ID         string         `json:"id"`
+	Name       *string        `json:"name,omitempty"`
+	Ready      *bool          `json:"ready,omitempty"`
+	Alive      *bool          `json:"alive,omitempty"`
+	X          *float64       `json:"x,omitempty"`
+	Y          *float64       `json:"y,omitempty"`
+	Size       *float64       `json:"size,omitempty"`
+	Facing     *int           `json:"facing,omitempty"`
+	Moving     *bool          `json:"moving,omitempty"`
+	WalkCycle  *float64       `json:"walkCycle,omitempty"`
+	StepAccum  *float64       `json:"stepAccumulator,omitempty"`
+	Score      *int           `json:"score,omitempty"`
+	Team       *int           `json:"team,omitempty"`
+	Health     *int           `json:"health,omitempty"`
+	Armor      *int           `json:"armor,omitempty"`
+	Weapon     *string        `json:"weapon,omitempty"`
+	Ammo       *int           `json:"ammo,omitempty"`
+	Cooldown   *float64       `json:"cooldown,omitempty"`
+	Charges    *int           `json:"charges,omitempty"`
+	AbilityCD  *float64       `json:"abilityCooldown,omitempty"`
+	Appearance *string        `json:"appearance,omitempty"`
+	Disguise   *string        `json:"disguise,omitempty"`
+	Effects    []StatusEffect `json:"effects,omitempty"`
 }
 
 type StatePatch struct {
@@ -124,12 +219,19 @@ type StatePatch struct {
 	HidePhase      *string        `json:"hidePhase,omitempty"`
 	ShootPhase     *string        `json:"shootPhase,omitempty"`
 	Message        *string        `json:"message,omitempty"`
-	SeekerID       *string        `json:"seekerId,omitempty"`
-	BombHolder     *string        `json:"bombHolder,omitempty"`
-	BombTimer      *float64       `json:"bombTimer,omitempty"`
+	SeekerIDs      []string       `json:"seekerIds,omitempty"`
+	Bombs          []Bomb         `json:"bombs,omitempty"`
 	WinnerID       *string        `json:"winnerId,omitempty"`
+	WinnerTeam     *int           `json:"winnerTeam,omitempty"`
 	Golden         *bool          `json:"goldenChainActive,omitempty"`
-	Status         *StatusEffect  `json:"statusEffect,omitempty"`
+	HostID         *string        `json:"hostId,omitempty"`
+	Settings       *RoomSettings  `json:"settings,omitempty"`
+	Zone           *SafeZone      `json:"zone,omitempty"`
+	Bases          []TeamBase     `json:"bases,omitempty"`
+	FishCarrier    *string        `json:"fishCarrier,omitempty"`
+	Hills          []HillZone     `json:"hills,omitempty"`
+	Pings          []SoundPing    `json:"pings,omitempty"`
+	Hazards        []HazardCell   `json:"hazards,omitempty"`
 	Fish           *FishState     `json:"fish,omitempty"`
 	PowerUp        *PowerUpState  `json:"powerUp,omitempty"`
 	PowerUps       []PowerUpState `json:"powerUps"`
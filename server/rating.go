@@ -0,0 +1,234 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type playerRating struct {
+	Rating    float64   `json:"rating"`
+	Games     int       `json:"games"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// finalRankingLocked orders the players of a finished round from best to
+// worst. Players sharing a tier are treated as a draw.
+func (r *room) finalRankingLocked() [][]string {
+	if r.teamsEnabled() {
+		return r.teamRankingLocked()
+	}
+	return r.mode().Ranking(r)
+}
+
+func (r *room) survivalRankingLocked() [][]string {
+	tiers := [][]string{}
+	alive := []string{}
+	for _, p := range r.alivePlayersLocked() {
+		alive = append(alive, p.ID)
+	}
+	if len(alive) > 0 {
+		sort.Strings(alive)
+		tiers = append(tiers, alive)
+	}
+	for i := len(r.eliminations) - 1; i >= 0; i-- {
+		if _, ok := r.players[r.eliminations[i]]; ok {
+			tiers = append(tiers, []string{r.eliminations[i]})
+		}
+	}
+	return tiers
+}
+
+func (r *room) shooterRankingLocked() [][]string {
+	place := make(map[string]int, len(r.players))
+	for i, tier := range r.survivalRankingLocked() {
+		for _, id := range tier {
+			place[id] = i
+		}
+	}
+	ids := make([]string, 0, len(r.players))
+	for id := range r.players {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := r.players[ids[i]], r.players[ids[j]]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return place[a.ID] < place[b.ID]
+	})
+	tiers := [][]string{}
+	for i, id := range ids {
+		if i > 0 {
+			prev := ids[i-1]
+			if r.players[prev].Score == r.players[id].Score && place[prev] == place[id] {
+				tiers[len(tiers)-1] = append(tiers[len(tiers)-1], id)
+				continue
+			}
+		}
+		tiers = append(tiers, []string{id})
+	}
+	return tiers
+}
+
+// hideSeekRankingLocked ranks surviving hiders first, then caught or
+// infected hiders by how long they lasted. The starting seekers share one
+// tier at the top when they won and at the bottom otherwise.
+func (r *room) hideSeekRankingLocked() [][]string {
+	caught := make(map[string]bool, len(r.eliminations))
+	for _, id := range r.eliminations {
+		caught[id] = true
+	}
+	hiders := [][]string{}
+	alive := []string{}
+	for _, id := range sortedPlayerIDs(r.players) {
+		if r.players[id].Alive && !r.isSeekerLocked(id) {
+			alive = append(alive, id)
+		}
+	}
+	if len(alive) > 0 {
+		hiders = append(hiders, alive)
+	}
+	for i := len(r.eliminations) - 1; i >= 0; i-- {
+		if _, ok := r.players[r.eliminations[i]]; ok {
+			hiders = append(hiders, []string{r.eliminations[i]})
+		}
+	}
+	seekers := []string{}
+	for _, id := range r.state.SeekerIDs {
+		if _, ok := r.players[id]; ok && !caught[id] {
+			seekers = append(seekers, id)
+		}
+	}
+	if len(seekers) == 0 {
+		return hiders
+	}
+	if r.isSeekerLocked(r.state.WinnerID) {
+		return append([][]string{seekers}, hiders...)
+	}
+	return append(hiders, seekers)
+}
+
+func (r *room) scoreRankingLocked() [][]string {
+	ids := make([]string, 0, len(r.players))
+	for id := range r.players {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return r.players[ids[i]].Score > r.players[ids[j]].Score
+	})
+	tiers := [][]string{}
+	for i, id := range ids {
+		if i > 0 && r.players[ids[i-1]].Score == r.players[id].Score {
+			tiers[len(tiers)-1] = append(tiers[len(tiers)-1], id)
+			continue
+		}
+		tiers = append(tiers, []string{id})
+	}
+	return tiers
+}
+
+func (s *server) ratingLocked(playerID, mode string) playerRating {
+	if byMode, ok := s.ratings[playerID]; ok {
+		if rating, ok := byMode[mode]; ok {
+			return rating
+		}
+	}
+	return playerRating{Rating: ratingInitial}
+}
+
+func (s *server) playerRating(playerID, mode string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ratingLocked(playerID, mode).Rating
+}
+
+func (s *server) averageRatingLocked(ids []string, mode string) float64 {
+	if len(ids) == 0 {
+		return ratingInitial
+	}
+	total := 0.0
+	for _, id := range ids {
+		total += s.ratingLocked(id, mode).Rating
+	}
+	return total / float64(len(ids))
+}
+
+func (s *server) averageRating(ids []string, mode string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.averageRatingLocked(ids, mode)
+}
+
+// recordRatingsLocked applies a multiplayer Elo update: every player is
+// compared against every other one and the K factor is split between the
+// opponents.
+func (s *server) recordRatingsLocked(mode string, tiers [][]string) {
+	place := make(map[string]int)
+	ids := []string{}
+	for i, tier := range tiers {
+		for _, id := range tier {
+			place[id] = i
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 {
+		return
+	}
+
+	before := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		before[id] = s.ratingLocked(id, mode).Rating
+	}
+	k := ratingK / float64(len(ids)-1)
+	now := time.Now()
+	for _, id := range ids {
+		delta := 0.0
+		for _, other := range ids {
+			if other == id {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, (before[other]-before[id])/400))
+			actual := 0.5
+			if place[id] < place[other] {
+				actual = 1
+			} else if place[id] > place[other] {
+				actual = 0
+			}
+			delta += k * (actual - expected)
+		}
+		rating := s.ratingLocked(id, mode)
+		rating.Rating = roundFloat(rating.Rating+delta, 1)
+		rating.Games++
+		rating.UpdatedAt = now
+		if s.ratings[id] == nil {
+			s.ratings[id] = make(map[string]playerRating)
+		}
+		s.ratings[id][mode] = rating
+	}
+}
+
+func (s *server) handlePlayerRating(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	ratings := make(map[string]playerRating)
+	if mode := r.URL.Query().Get("mode"); mode != "" {
+		mode = normalizeMode(mode)
+		ratings[mode] = s.ratingLocked(id, mode)
+	} else {
+		for mode, rating := range s.ratings[id] {
+			ratings[mode] = rating
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, map[string]any{"playerId": id, "ratings": ratings})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type roomSettings struct {
+	RoundDuration        float64 `json:"roundDuration"`
+	ShooterRoundDuration float64 `json:"shooterRoundDuration"`
+	ShooterPrepDuration  float64 `json:"shooterPrepDuration"`
+	BombTimerDuration    float64 `json:"bombTimerDuration"`
+	BombTimerBonus       float64 `json:"bombTimerBonus"`
+	ShooterDamage        int     `json:"shooterDamage"`
+	ShooterMaxHealth     int     `json:"shooterMaxHealth"`
+	HideDuration         float64 `json:"hideDuration"`
+	SeekDuration         float64 `json:"seekDuration"`
+	MaxMines             int     `json:"maxMines"`
+	WorldScale           float64 `json:"worldScale"`
+	FishPoints           int     `json:"fishPoints"`
+	GoldenFishPoints     int     `json:"goldenFishPoints"`
+	GoldenFishChance     float64 `json:"goldenFishChance"`
+	TimeFishChance       float64 `json:"timeFishChance"`
+	TimeFishSeconds      float64 `json:"timeFishSeconds"`
+	GoldenChainDuration  float64 `json:"goldenChainDuration"`
+	Teams                int     `json:"teams"`
+	FriendlyFire         bool    `json:"friendlyFire"`
+	AutoBalance          bool    `json:"autoBalance"`
+	Infection            bool    `json:"infection"`
+	BombBlastRadius      float64 `json:"bombBlastRadius"`
+	HotPotato            bool    `json:"hotPotato"`
+	ArenaEventInterval   float64 `json:"arenaEventInterval"`
+	Map                  string  `json:"map"`
+}
+
+func defaultRoomSettings(mode string) roomSettings {
+	settings := roomSettings{
+		RoundDuration:        roundDuration.Seconds(),
+		ShooterRoundDuration: shooterRoundDuration,
+		ShooterPrepDuration:  shooterPrepDuration,
+		BombTimerDuration:    bombTimerDuration,
+		BombTimerBonus:       bombTimerBonus,
+		BombBlastRadius:      bombBlastRadius,
+		ShooterDamage:        shooterDamage,
+		ShooterMaxHealth:     shooterMaxHealth,
+		HideDuration:         hideSeekHideDuration,
+		SeekDuration:         hideSeekSeekDuration,
+		MaxMines:             maxMines,
+		WorldScale:           1,
+		FishPoints:           fishPoints,
+		GoldenFishPoints:     goldenFishPoints,
+		GoldenFishChance:     goldenFishChance,
+		TimeFishChance:       timeFishChance,
+		TimeFishSeconds:      timeFishSeconds,
+		GoldenChainDuration:  goldenChainDuration,
+		AutoBalance:          true,
+	}
+	if m, ok := lookupMode(mode); ok {
+		m.Defaults(&settings)
+	}
+	return settings
+}
+
+func checkFloatRange(name string, v, min, max float64) error {
+	if v < min || v > max {
+		return fmt.Errorf("%s must be between %g and %g", name, min, max)
+	}
+	return nil
+}
+
+func checkIntRange(name string, v, min, max int) error {
+	if v < min || v > max {
+		return fmt.Errorf("%s must be between %d and %d", name, min, max)
+	}
+	return nil
+}
+
+func (s roomSettings) validate() error {
+	checks := []error{
+		checkFloatRange("roundDuration", s.RoundDuration, 10, 600),
+		checkFloatRange("shooterRoundDuration", s.ShooterRoundDuration, 30, 900),
+		checkFloatRange("shooterPrepDuration", s.ShooterPrepDuration, 0, 120),
+		checkFloatRange("bombTimerDuration", s.BombTimerDuration, 3, 120),
+		checkFloatRange("bombTimerBonus", s.BombTimerBonus, 0, 60),
+		checkFloatRange("bombBlastRadius", s.BombBlastRadius, 0, 400),
+		checkIntRange("shooterDamage", s.ShooterDamage, 1, 1000),
+		checkIntRange("shooterMaxHealth", s.ShooterMaxHealth, 1, 1000),
+		checkFloatRange("hideDuration", s.HideDuration, 5, 300),
+		checkFloatRange("seekDuration", s.SeekDuration, 10, 900),
+		checkIntRange("maxMines", s.MaxMines, 0, 32),
+		checkFloatRange("worldScale", s.WorldScale, 1, 8),
+		checkIntRange("fishPoints", s.FishPoints, 1, 100),
+		checkIntRange("goldenFishPoints", s.GoldenFishPoints, 1, 100),
+		checkFloatRange("goldenFishChance", s.GoldenFishChance, 0, 1),
+		checkFloatRange("timeFishChance", s.TimeFishChance, 0, 1-s.GoldenFishChance),
+		checkFloatRange("timeFishSeconds", s.TimeFishSeconds, 0, 60),
+		checkFloatRange("goldenChainDuration", s.GoldenChainDuration, 0, 60),
+		checkIntRange("teams", s.Teams, 0, maxTeams),
+		checkFloatRange("arenaEventInterval", s.ArenaEventInterval, 0, 300),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
+	if s.Teams == 1 {
+		return fmt.Errorf("teams must be 0 (free-for-all) or at least 2")
+	}
+	if s.ArenaEventInterval > 0 && s.ArenaEventInterval < 5 {
+		return fmt.Errorf("arenaEventInterval must be 0 (off) or at least 5")
+	}
+	return nil
+}
+
+// parseRoomSettings overlays the JSON payload on top of base so that clients
+// only have to send the values they want to change.
+func parseRoomSettings(base roomSettings, raw []byte) (roomSettings, error) {
+	settings := base
+	if len(raw) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return roomSettings{}, fmt.Errorf("invalid settings payload: %w", err)
+	}
+	if err := settings.validate(); err != nil {
+		return roomSettings{}, err
+	}
+	return settings, nil
+}
+
+func (r *room) applySettingsLocked(playerID string, settings roomSettings) error {
+	if playerID != r.state.HostID {
+		return fmt.Errorf("only the host can change room settings")
+	}
+	if r.state.Phase != "lobby" && r.state.Phase != "ended" {
+		return fmt.Errorf("settings can only be changed between rounds")
+	}
+	if err := settings.validate(); err != nil {
+		return err
+	}
+	if r.server != nil {
+		if err := r.server.checkMapSettings(r.state.Mode, &settings); err != nil {
+			return err
+		}
+	}
+	r.state.Settings = settings
+	r.state.Remaining = settings.RoundDuration
+	for _, p := range r.players {
+		r.assignTeamLocked(p)
+	}
+	r.resolvePlayersAfterWallChangeLocked()
+	return nil
+}
+
+func (r *room) pickHostLocked() {
+	if _, ok := r.players[r.state.HostID]; ok {
+		return
+	}
+	r.state.HostID = ""
+	for id := range r.players {
+		if r.state.HostID == "" || id < r.state.HostID {
+			r.state.HostID = id
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+type safeZone struct {
+	X            float64 `json:"x"`
+	Y            float64 `json:"y"`
+	Radius       float64 `json:"radius"`
+	TargetRadius float64 `json:"targetRadius"`
+}
+
+func isShooterSupplyItem(itemType string) bool {
+	return itemType == "health" || itemType == "armor"
+}
+
+// applyShooterSupplyLocked consumes a health or armor pickup. Full health
+// or armor leaves the item on the ground for someone else.
+func (r *room) applyShooterSupplyLocked(p *playerState, item *powerUpState) bool {
+	switch item.Type {
+	case "health":
+		maxHealth := r.state.Settings.ShooterMaxHealth
+		if p.Health >= maxHealth {
+			return false
+		}
+		p.Health = min(p.Health+shooterHealAmount, maxHealth)
+		r.state.Message = fmt.Sprintf("%s подлечился", fallbackName(p.Name))
+	case "armor":
+		if p.Armor >= shooterMaxArmor {
+			return false
+		}
+		p.Armor = min(p.Armor+shooterArmorAmount, shooterMaxArmor)
+		r.state.Message = fmt.Sprintf("%s надел броню", fallbackName(p.Name))
+	default:
+		return false
+	}
+	return true
+}
+
+func (r *room) updateShooterSuppliesLocked() {
+	if !r.shootingUnlocked {
+		return
+	}
+	supplies := 0
+	for i := len(r.state.PowerUps) - 1; i >= 0; i-- {
+		item := &r.state.PowerUps[i]
+		if !isShooterSupplyItem(item.Type) {
+			continue
+		}
+		item.Remaining -= tickRate.Seconds()
+		if item.Remaining <= 0 || !item.Active {
+			r.state.PowerUps = append(r.state.PowerUps[:i], r.state.PowerUps[i+1:]...)
+			continue
+		}
+		supplies++
+	}
+	if supplies >= shooterSupplyMax {
+		return
+	}
+	r.shooterSupplyTimer -= tickRate.Seconds()
+	if r.shooterSupplyTimer > 0 {
+		return
+	}
+	r.shooterSupplyTimer = shooterSupplyInterval
+	itemType := "health"
+	if rand.Float64() < 0.4 {
+		itemType = "armor"
+	}
+	if x, y, ok := r.findSupplySpotLocked(); ok {
+		r.state.PowerUps = append(r.state.PowerUps, powerUpState{X: x, Y: y, Size: powerUpSize, Active: true, Remaining: shooterSupplyLifetime, Type: itemType})
+	}
+}
+
+// findSupplySpotLocked prefers spots inside the safe zone so that pickups
+// do not lure players into the storm.
+func (r *room) findSupplySpotLocked() (float64, float64, bool) {
+	margin := 36.0
+	world := r.currentWorldSize()
+	reach := r.reachableAreaLocked()
+	for attempt := 0; attempt < 60; attempt++ {
+		x := margin + rand.Float64()*(world-margin*2)
+		y := margin + rand.Float64()*(world-margin*2)
+		if !r.itemSpotFreeLocked(x, y, powerUpSize/2, reach) {
+			continue
+		}
+		if zone := r.state.Zone; zone != nil && math.Hypot(x-zone.X, y-zone.Y) > zone.TargetRadius+(zone.Radius-zone.TargetRadius)/2 {
+			continue
+		}
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+func (r *room) startSafeZoneLocked() {
+	world := r.currentWorldSize()
+	r.state.Zone = &safeZone{
+		X:            world / 2,
+		Y:            world / 2,
+		Radius:       world * shooterZoneStartRate,
+		TargetRadius: world * shooterZoneEndRate,
+	}
+	r.zoneShrinkRate = 0
+	if r.state.Remaining > 0 {
+		r.zoneShrinkRate = (r.state.Zone.Radius - r.state.Zone.TargetRadius) / r.state.Remaining
+	}
+	r.zoneDamage = make(map[string]float64)
+}
+
+func (r *room) updateSafeZoneLocked() {
+	zone := r.state.Zone
+	if zone == nil {
+		return
+	}
+	zone.Radius = math.Max(zone.Radius-r.zoneShrinkRate*tickRate.Seconds(), zone.TargetRadius)
+	for id, p := range r.players {
+		if !p.Alive {
+			continue
+		}
+		if math.Hypot(p.X-zone.X, p.Y-zone.Y) <= zone.Radius {
+			delete(r.zoneDamage, id)
+			continue
+		}
+		r.zoneDamage[id] += shooterZoneDamage * tickRate.Seconds()
+		whole := math.Floor(r.zoneDamage[id])
+		if whole < 1 {
+			continue
+		}
+		r.zoneDamage[id] -= whole
+		p.Health -= int(whole)
+		if p.Health <= 0 {
+			p.Health = 0
+			r.recordEliminationLocked(p)
+			r.roundStatsLocked(id).ShooterDeaths++
+			r.state.Message = fmt.Sprintf("%s не успел в безопасную зону", fallbackName(p.Name))
+		}
+	}
+}
+
+type shooterMode struct{}
+
+func init() {
+	registerMode(shooterMode{})
+}
+
+func (shooterMode) Name() string { return "shooters" }
+
+func (shooterMode) Defaults(settings *roomSettings) {
+	settings.WorldScale = shooterWorldScale
+}
+
+func (shooterMode) WallParams() wallParams { return arenaWallParams }
+
+func (shooterMode) ItemTypes() map[string]uint8 {
+	return map[string]uint8{
+		"blaster": 13,
+		"laser":   14,
+		"pistol":  15,
+		"plasma":  16,
+		"health":  17,
+		"armor":   18,
+	}
+}
+
+func (shooterMode) StartRound(r *room) {
+	r.clearModeEntitiesLocked()
+	r.buildArenaWithWallsLocked()
+	r.state.PowerUps = r.spawnShooterLootLocked()
+	r.state.Remaining = r.state.Settings.ShooterRoundDuration
+	r.state.Countdown = r.state.Settings.ShooterPrepDuration
+	r.state.ShootPhase = "loot"
+	r.shootingUnlocked = false
+	r.state.Message = "Подготовка: найдите оружие!"
+}
+
+func (shooterMode) Tick(r *room) {
+	r.state.Remaining -= tickRate.Seconds()
+	r.updatePlayersLocked()
+	r.tickShooterPhaseLocked()
+	r.tickWeaponCooldownsLocked()
+	if r.shootingUnlocked {
+		r.resolveShooterCombatLocked()
+		r.updateShooterSuppliesLocked()
+		r.updateSafeZoneLocked()
+	}
+	r.updateShotsLocked()
+	if r.state.Remaining <= 0 {
+		r.endRoundLocked("Время вышло")
+		return
+	}
+	if r.roundDecidedLocked() {
+		r.endRoundLocked(r.lastStandingMessageLocked())
+	}
+}
+
+func (shooterMode) Pickup(r *room, p *playerState) {
+	r.collectShooterLootLocked(p)
+}
+
+func (shooterMode) Collide(r *room) {}
+
+func (shooterMode) Winner(r *room) string {
+	if alive := r.alivePlayersLocked(); len(alive) == 1 {
+		return alive[0].ID
+	}
+	return r.topScorerLocked()
+}
+
+func (shooterMode) MatchRule() matchRule { return matchRule{Min: 2, Ideal: 6, Max: 12} }
+
+// Team deathmatch is optional.
+func (shooterMode) Teams(settings roomSettings) teamRule {
+	return teamRule{Count: settings.Teams}
+}
+
+func (shooterMode) Ranking(r *room) [][]string { return r.shooterRankingLocked() }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// spawnWallsLocked returns the walls cats must keep clear of. Before the
+// round lays out the arena they are the map walls or the boundary.
+func (r *room) spawnWallsLocked() []wall {
+	if len(r.state.Walls) > 0 {
+		return r.state.Walls
+	}
+	if m := r.activeMapLocked(); m != nil {
+		return m.wallsFor(r.mode())
+	}
+	return r.buildBoundaryWalls(r.currentWorldSize())
+}
+
+// spawnCandidatesLocked returns the spots a cat may start on: the map's
+// spawn points with a ring around each for crowded rooms, or the centres of
+// the inner grid cells of a generated arena.
+func (r *room) spawnCandidatesLocked() []vector {
+	var candidates []vector
+	if m := r.activeMapLocked(); m != nil {
+		for _, sp := range m.Spawns {
+			candidates = append(candidates, sp)
+			for i := 0; i < 8; i++ {
+				angle := float64(i) * math.Pi / 4
+				candidates = append(candidates, vector{X: sp.X + math.Cos(angle)*catSize*1.2, Y: sp.Y + math.Sin(angle)*catSize*1.2})
+			}
+		}
+	} else {
+		world := r.currentWorldSize()
+		n := gridDimension(world)
+		cellSize := world / float64(n)
+		for row := 1; row < n-1; row++ {
+			for col := 1; col < n-1; col++ {
+				candidates = append(candidates, vector{X: (float64(col) + 0.5) * cellSize, Y: (float64(row) + 0.5) * cellSize})
+			}
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	return candidates
+}
+
+// isSpawnSafeLocked keeps a new cat clear of walls, mines, the fish and
+// collapsing floor, and out of sealed-off parts of the arena.
+func (r *room) isSpawnSafeLocked(pos vector, walls []wall, reach [][]bool) bool {
+	world := r.currentWorldSize()
+	radius := catSize / 2
+	if !circleInsideWorld(pos.X, pos.Y, radius, world) || circleIntersectsAnyWall(pos.X, pos.Y, radius+spawnWallClearance, walls) {
+		return false
+	}
+	for _, m := range r.state.Mines {
+		if math.Hypot(pos.X-m.X, pos.Y-m.Y) < m.Size/2+radius+spawnMineClearance {
+			return false
+		}
+	}
+	if r.state.Fish.Alive && math.Hypot(pos.X-r.state.Fish.X, pos.Y-r.state.Fish.Y) < spawnFishClearance {
+		return false
+	}
+	cell := positionToGridCell(pos.X, pos.Y, world)
+	return reach[cell.Row][cell.Col]
+}
+
+// pickSpawnLocked returns the safe candidate farthest from everything in
+// avoid. Without anything to avoid it is a random safe candidate.
+func (r *room) pickSpawnLocked(candidates, avoid []vector) (vector, bool) {
+	walls := r.spawnWallsLocked()
+	reach := r.reachableAreaLocked()
+	best, bestDist, found := vector{}, -1.0, false
+	for _, c := range candidates {
+		if !r.isSpawnSafeLocked(c, walls, reach) {
+			continue
+		}
+		dist := math.Inf(1)
+		for _, a := range avoid {
+			dist = math.Min(dist, math.Hypot(c.X-a.X, c.Y-a.Y))
+		}
+		if dist > bestDist {
+			best, bestDist, found = c, dist, true
+		}
+	}
+	return best, found
+}
+
+// spawnPointLocked finds a start for a cat joining mid-game, away from the
+// cats already in the arena.
+func (r *room) spawnPointLocked() vector {
+	var others []vector
+	for _, p := range r.players {
+		if p.Alive {
+			others = append(others, vector{X: p.X, Y: p.Y})
+		}
+	}
+	if pos, ok := r.pickSpawnLocked(r.spawnCandidatesLocked(), others); ok {
+		return pos
+	}
+	world := r.currentWorldSize()
+	return vector{X: world / 2, Y: world / 2}
+}
+
+// spreadPlayersLocked moves every cat to a fresh start at round start, each
+// as far as possible from the ones placed before it.
+func (r *room) spreadPlayersLocked() {
+	candidates := r.spawnCandidatesLocked()
+	ids := sortedPlayerIDs(r.players)
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	placed := make([]vector, 0, len(ids))
+	for _, id := range ids {
+		pos, ok := r.pickSpawnLocked(candidates, placed)
+		if !ok {
+			continue
+		}
+		r.players[id].X, r.players[id].Y = pos.X, pos.Y
+		placed = append(placed, pos)
+	}
+}
+
+// placeSeekersAwayLocked moves the hide-and-seek seekers to the starts
+// farthest from every hider so nobody is caught right at the start.
+func (r *room) placeSeekersAwayLocked() {
+	var hiders []vector
+	for id, p := range r.players {
+		if !r.isSeekerLocked(id) {
+			hiders = append(hiders, vector{X: p.X, Y: p.Y})
+		}
+	}
+	if len(hiders) == 0 {
+		return
+	}
+	candidates := r.spawnCandidatesLocked()
+	for _, id := range r.state.SeekerIDs {
+		p, ok := r.players[id]
+		if !ok {
+			continue
+		}
+		pos, ok := r.pickSpawnLocked(candidates, hiders)
+		if !ok {
+			continue
+		}
+		p.X, p.Y = pos.X, pos.Y
+		// The next seeker should not land on the same spot.
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if math.Hypot(c.X-pos.X, c.Y-pos.Y) >= catSize {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+}
+
+// nearCatLocked reports whether a living cat is within distance of the
+// point; items use it to keep from appearing under someone's paws.
+func (r *room) nearCatLocked(x, y, distance float64) bool {
+	for _, p := range r.players {
+		if p.Alive && math.Hypot(x-p.X, y-p.Y) < distance {
+			return true
+		}
+	}
+	return false
+}
+
+// reachableAreaLocked marks the grid cells connected to the arena objective;
+// wall segments and collapsing floor block the rest.
+func (r *room) reachableAreaLocked() [][]bool {
+	blocked := r.arenaBlockedGridLocked()
+	objective := r.wallObjective
+	if objective.Row >= len(blocked) || objective.Col >= len(blocked) {
+		objective = gridCell{Row: len(blocked) / 2, Col: len(blocked) / 2}
+	}
+	reach := reachableCells(objective, blocked)
+	if blocked[objective.Row][objective.Col] {
+		reach[objective.Row][objective.Col] = false
+	}
+	return reach
+}
+
+// itemSpotFreeLocked reports whether an item of the given radius can lie at
+// (x, y): clear of walls and in a part of the arena cats can reach.
+func (r *room) itemSpotFreeLocked(x, y, radius float64, reach [][]bool) bool {
+	if circleIntersectsAnyWall(x, y, radius+2, r.state.Walls) {
+		return false
+	}
+	cell := positionToGridCell(x, y, r.currentWorldSize())
+	return reach[cell.Row][cell.Col]
+}
+
+// itemPositionLocked looks for a free spot among random item positions.
+func (r *room) itemPositionLocked(radius, margin float64, reach [][]bool) (float64, float64, bool) {
+	for attempt := 0; attempt < 60; attempt++ {
+		x, y := r.randomItemPositionLocked(margin)
+		if r.itemSpotFreeLocked(x, y, radius, reach) {
+			return x, y, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,121 @@
+package main
+
+import "net/http"
+
+type playerStats struct {
+	RoundsPlayed  map[string]int `json:"roundsPlayed"`
+	RoundsWon     map[string]int `json:"roundsWon"`
+	FishCaught    int            `json:"fishCaught"`
+	GoldenFish    int            `json:"goldenFish"`
+	BombsPassed   int            `json:"bombsPassed"`
+	TimesExploded int            `json:"timesExploded"`
+	ShooterKills  int            `json:"shooterKills"`
+	ShooterDeaths int            `json:"shooterDeaths"`
+	ShotsFired    int            `json:"shotsFired"`
+	ShotsHit      int            `json:"shotsHit"`
+	HidersFound   int            `json:"hidersFound"`
+	SecondsHidden float64        `json:"secondsHidden"`
+}
+
+func newPlayerStats() *playerStats {
+	return &playerStats{RoundsPlayed: make(map[string]int), RoundsWon: make(map[string]int)}
+}
+
+func (s *playerStats) add(delta *playerStats) {
+	for mode, count := range delta.RoundsPlayed {
+		s.RoundsPlayed[mode] += count
+	}
+	for mode, count := range delta.RoundsWon {
+		s.RoundsWon[mode] += count
+	}
+	s.FishCaught += delta.FishCaught
+	s.GoldenFish += delta.GoldenFish
+	s.BombsPassed += delta.BombsPassed
+	s.TimesExploded += delta.TimesExploded
+	s.ShooterKills += delta.ShooterKills
+	s.ShooterDeaths += delta.ShooterDeaths
+	s.ShotsFired += delta.ShotsFired
+	s.ShotsHit += delta.ShotsHit
+	s.HidersFound += delta.HidersFound
+	s.SecondsHidden += delta.SecondsHidden
+}
+
+func (s *playerStats) accuracy() float64 {
+	if s.ShotsFired == 0 {
+		return 0
+	}
+	return roundFloat(float64(s.ShotsHit)/float64(s.ShotsFired), 3)
+}
+
+// roundStatsLocked returns the per-round counters of a player; they are
+// merged into the lifetime stats when the round ends.
+func (r *room) roundStatsLocked(playerID string) *playerStats {
+	stats, ok := r.roundStats[playerID]
+	if !ok {
+		stats = newPlayerStats()
+		r.roundStats[playerID] = stats
+	}
+	return stats
+}
+
+// flushRoundStatsLocked hands the round's ranking and counters to the
+// server, which saves them together with anything else changed during the
+// round.
+func (r *room) flushRoundStatsLocked(ranking [][]string) {
+	mode := r.state.Mode
+	for id := range r.players {
+		stats := r.roundStatsLocked(id)
+		stats.RoundsPlayed[mode]++
+		if id == r.state.WinnerID || (r.state.WinnerTeam != 0 && r.players[id].Team == r.state.WinnerTeam) {
+			stats.RoundsWon[mode]++
+		}
+	}
+	deltas := r.roundStats
+	r.roundStats = make(map[string]*playerStats)
+	go r.server.recordRound(mode, ranking, deltas)
+}
+
+// recordRound applies a finished round and writes data.json once.
+func (s *server) recordRound(mode string, ranking [][]string, deltas map[string]*playerStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordRatingsLocked(mode, ranking)
+	s.recordStatsLocked(deltas)
+	s.persistLocked()
+}
+
+func (s *server) recordStatsLocked(deltas map[string]*playerStats) {
+	for id, delta := range deltas {
+		stats, ok := s.stats[id]
+		if !ok {
+			stats = newPlayerStats()
+			s.stats[id] = stats
+		}
+		stats.add(delta)
+	}
+}
+
+func (s *server) handlePlayerStats(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	stats := newPlayerStats()
+	if existing, ok := s.stats[id]; ok {
+		stats.add(existing)
+	}
+	name := s.cats[id].Name
+	s.mu.Unlock()
+	writeJSON(w, map[string]any{
+		"playerId": id,
+		"name":     name,
+		"stats":    stats,
+		"accuracy": stats.accuracy(),
+	})
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// teamRule is a mode's take on teams. Count is zero for free-for-all modes.
+type teamRule struct {
+	Count int
+	// Survival scores teams by living players instead of summed scores.
+	Survival bool
+}
+
+// teamsEnabled reports whether the room plays in teams.
+func (r *room) teamsEnabled() bool {
+	return r.teamCount() >= 2
+}
+
+func (r *room) teamCount() int {
+	return r.mode().Teams(r.state.Settings).Count
+}
+
+func sameTeam(a, b *playerState) bool {
+	return a != nil && b != nil && a.Team != 0 && a.Team == b.Team
+}
+
+func (r *room) canDamageLocked(shooter, target *playerState) bool {
+	if !r.teamsEnabled() || r.state.Settings.FriendlyFire {
+		return true
+	}
+	return !sameTeam(shooter, target)
+}
+
+// canReceiveBombLocked implements team bomb-pass: the bomb may only go to
+// a player of an opposing team.
+func (r *room) canReceiveBombLocked(holder, target *playerState) bool {
+	if !r.teamsEnabled() {
+		return true
+	}
+	return !sameTeam(holder, target)
+}
+
+func (r *room) teamSizesLocked() []int {
+	sizes := make([]int, r.teamCount()+1)
+	for _, p := range r.players {
+		if p.Team > 0 && p.Team < len(sizes) {
+			sizes[p.Team]++
+		}
+	}
+	return sizes
+}
+
+func (r *room) assignTeamLocked(p *playerState) {
+	if !r.teamsEnabled() {
+		p.Team = 0
+		return
+	}
+	if p.Team > 0 && p.Team <= r.teamCount() {
+		return
+	}
+	p.Team = 0
+	sizes := r.teamSizesLocked()
+	smallest := 1
+	for team := 2; team < len(sizes); team++ {
+		if sizes[team] < sizes[smallest] {
+			smallest = team
+		}
+	}
+	p.Team = smallest
+}
+
+// balanceTeamsLocked runs at round start. With auto-balance every player is
+// reshuffled, which is why setTeamLocked refuses picks while it is on;
+// otherwise lobby choices are kept and only players without a valid team
+// are placed into the smallest one.
+func (r *room) balanceTeamsLocked() {
+	ids := sortedPlayerIDs(r.players)
+	if !r.teamsEnabled() {
+		for _, id := range ids {
+			r.players[id].Team = 0
+		}
+		return
+	}
+	if r.state.Settings.AutoBalance {
+		rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		for i, id := range ids {
+			r.players[id].Team = i%r.teamCount() + 1
+		}
+		return
+	}
+	for _, id := range ids {
+		r.assignTeamLocked(r.players[id])
+	}
+}
+
+func (r *room) setTeam(playerID string, team int) {
+	r.mu.Lock()
+	err := r.setTeamLocked(playerID, team)
+	var conns []*clientConn
+	if err != nil {
+		conns = r.playerConnsLocked(playerID)
+	}
+	r.mu.Unlock()
+	if err == nil {
+		return
+	}
+	data, _ := json.Marshal(wsMessage{Type: "error", Error: "Не удалось сменить команду: " + err.Error()})
+	for _, client := range conns {
+		client.send(websocket.TextMessage, data)
+	}
+}
+
+func (r *room) setTeamLocked(playerID string, team int) error {
+	p, ok := r.players[playerID]
+	if !ok {
+		return fmt.Errorf("unknown player")
+	}
+	if !r.teamsEnabled() {
+		return fmt.Errorf("teams are disabled in this room")
+	}
+	if r.state.Settings.AutoBalance {
+		return fmt.Errorf("teams are auto-balanced; turn off auto-balance to pick a team")
+	}
+	if r.state.Phase != "lobby" && r.state.Phase != "ended" {
+		return fmt.Errorf("team can only be changed between rounds")
+	}
+	if team < 1 || team > r.teamCount() {
+		return fmt.Errorf("team must be between 1 and %d", r.teamCount())
+	}
+	p.Team = team
+	return nil
+}
+
+func (r *room) aliveTeamsLocked() map[int]int {
+	alive := make(map[int]int)
+	for _, p := range r.players {
+		if p.Alive {
+			alive[p.Team]++
+		}
+	}
+	return alive
+}
+
+// roundDecidedLocked reports whether at most one side is still standing.
+func (r *room) roundDecidedLocked() bool {
+	if r.teamsEnabled() {
+		return len(r.aliveTeamsLocked()) <= 1
+	}
+	return r.countAlivePlayersLocked() <= 1
+}
+
+func (r *room) lastStandingMessageLocked() string {
+	if r.teamsEnabled() {
+		return "Осталась только одна команда!"
+	}
+	return "Выжил только один котик!"
+}
+
+// teamScoresLocked sums player scores per team, or counts survivors in
+// modes that rank teams by survival.
+func (r *room) teamScoresLocked() map[int]int {
+	survival := r.mode().Teams(r.state.Settings).Survival
+	scores := make(map[int]int)
+	for team := 1; team <= r.teamCount(); team++ {
+		scores[team] = 0
+	}
+	for _, p := range r.players {
+		if p.Team == 0 {
+			continue
+		}
+		if survival {
+			if p.Alive {
+				scores[p.Team]++
+			}
+			continue
+		}
+		scores[p.Team] += p.Score
+	}
+	return scores
+}
+
+// teamOrderLocked sorts teams from best to worst: a team that is the only
+// one left standing wins outright, then score, then survivors.
+func (r *room) teamOrderLocked() []int {
+	scores := r.teamScoresLocked()
+	alive := r.aliveTeamsLocked()
+	soleSurvivor := 0
+	if len(alive) == 1 {
+		for team := range alive {
+			soleSurvivor = team
+		}
+	}
+	teams := make([]int, 0, len(scores))
+	for team := range scores {
+		teams = append(teams, team)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		a, b := teams[i], teams[j]
+		if (a == soleSurvivor) != (b == soleSurvivor) {
+			return a == soleSurvivor
+		}
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if alive[a] != alive[b] {
+			return alive[a] > alive[b]
+		}
+		return a < b
+	})
+	return teams
+}
+
+func (r *room) teamsTiedLocked(a, b int) bool {
+	scores := r.teamScoresLocked()
+	alive := r.aliveTeamsLocked()
+	return scores[a] == scores[b] && alive[a] == alive[b]
+}
+
+// winningTeamLocked returns 0 when teams are disabled or the top teams are
+// tied.
+func (r *room) winningTeamLocked() int {
+	if !r.teamsEnabled() {
+		return 0
+	}
+	order := r.teamOrderLocked()
+	if len(order) == 0 {
+		return 0
+	}
+	if len(order) > 1 && len(r.aliveTeamsLocked()) != 1 && r.teamsTiedLocked(order[0], order[1]) {
+		return 0
+	}
+	return order[0]
+}
+
+// bestTeamPlayerLocked picks the MVP of a team, preferring survivors.
+func (r *room) bestTeamPlayerLocked(team int) string {
+	var best *playerState
+	for _, p := range r.players {
+		if p.Team != team {
+			continue
+		}
+		if best == nil || (p.Alive && !best.Alive) || (p.Alive == best.Alive && (p.Score > best.Score || (p.Score == best.Score && p.ID < best.ID))) {
+			best = p
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.ID
+}
+
+func (r *room) teamRankingLocked() [][]string {
+	tiers := [][]string{}
+	order := r.teamOrderLocked()
+	for i, team := range order {
+		members := []string{}
+		for id, p := range r.players {
+			if p.Team == team {
+				members = append(members, id)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		sort.Strings(members)
+		if i > 0 && len(tiers) > 0 && r.teamsTiedLocked(order[i-1], team) {
+			tiers[len(tiers)-1] = append(tiers[len(tiers)-1], members...)
+			continue
+		}
+		tiers = append(tiers, members)
+	}
+	return tiers
+}
@@ -1,57 +1,133 @@
 package main
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 const (
-	worldSize             = 500.0
-	bombWorldScale        = 5.0
-	hideSeekWorldScale    = 3.0
-	shooterWorldScale     = 3.0
-	hideSeekHideDuration  = 60.0
-	hideSeekSeekDuration  = 180.0
-	hideSeekSeekerBoost   = 1.2
-	shooterPrepDuration   = 30.0
-	shooterRoundDuration  = 180.0
-	shooterDamage         = 34
-	shooterMaxHealth      = 100
-	shooterShotLifetime   = 0.35
-	shooterShotRange      = 220.0
-	catSpeed              = 180.0
-	catSize               = 36.0
-	fishSize              = 28.0
-	fishSwimSpeed         = 36.0
-	gridSize              = 10
-	wallThicknessRate     = 0.6
-	bombWallThicknessRate = 0.35
-	gridCellSize          = worldSize / gridSize
-	wallThickness         = gridCellSize * wallThicknessRate
-	maxWallTotalLen       = 10
-	bombMaxWallTotalLen   = 160
-	bombMaxSegments       = 20
-	maxSegments           = 2
-	tickRate              = time.Second / 60
-	broadcastRate         = time.Second / 15
-	countdownDuration     = 3 * time.Second
-	roundDuration         = 60 * time.Second
-	fishCatchDistance     = 34.0
-	maxMines              = 3
-	mineSize              = 26.0
-	mineMinDistance       = 25.0
-	powerUpSize           = 34.0
-	powerUpChance         = 0.05
-	powerUpLifetime       = 5.0
-	bombPowerUpLifetime   = 30.0
-	powerUpDuration       = 30.0
-	timeIncreaseLimit     = 15.0
-	timeDecreaseLimit     = 5.0
-	bombPowerUpInterval   = 5.0
-	bombPowerUpMax        = 10
-	bombTimerDuration     = 30.0
-	bombSlowDuration      = 1.0
-	bombSlowFactor        = 0.6
-	bombTimerBonus        = 10.0
-	dataFileName          = "data.json"
-	reconnectGrace        = 10 * time.Second
+	worldSize               = 500.0
+	bombWorldScale          = 5.0
+	hideSeekWorldScale      = 3.0
+	shooterWorldScale       = 3.0
+	hideSeekHideDuration    = 60.0
+	hideSeekSeekDuration    = 180.0
+	hideSeekSeekerBoost     = 1.2
+	shooterPrepDuration     = 30.0
+	shooterRoundDuration    = 180.0
+	shooterDamage           = 34
+	shooterMaxHealth        = 100
+	shooterShotLifetime     = 0.35
+	shooterShotRange        = 220.0
+	shooterHealAmount       = 40
+	shooterArmorAmount      = 50
+	shooterMaxArmor         = 100
+	shooterSupplyInterval   = 8.0
+	shooterSupplyMax        = 6
+	shooterSupplyLifetime   = 20.0
+	shooterZoneStartRate    = 0.75
+	shooterZoneEndRate      = 0.1
+	shooterZoneDamage       = 10.0
+	catSpeed                = 180.0
+	catSize                 = 36.0
+	fishSize                = 28.0
+	fishSwimSpeed           = 36.0
+	gridSize                = 10
+	wallThicknessRate       = 0.6
+	bombWallThicknessRate   = 0.35
+	gridCellSize            = worldSize / gridSize
+	wallThickness           = gridCellSize * wallThicknessRate
+	maxWallTotalLen         = 10
+	bombMaxWallTotalLen     = 160
+	bombMaxSegments         = 20
+	maxSegments             = 2
+	tickRate                = time.Second / 60
+	broadcastRate           = time.Second / 15
+	countdownDuration       = 3 * time.Second
+	roundDuration           = 60 * time.Second
+	fishCatchDistance       = 34.0
+	maxMines                = 3
+	mineSize                = 26.0
+	mineMinDistance         = 25.0
+	spawnWallClearance      = 4.0
+	spawnMineClearance      = 40.0
+	spawnFishClearance      = 90.0
+	powerUpSize             = 34.0
+	powerUpChance           = 0.05
+	powerUpLifetime         = 5.0
+	bombPowerUpLifetime     = 30.0
+	powerUpDuration         = 30.0
+	invertDuration          = 8.0
+	magnetDuration          = 10.0
+	magnetPullSpeed         = 90.0
+	ghostDuration           = 3.0
+	timeIncreaseLimit       = 15.0
+	timeDecreaseLimit       = 5.0
+	bombPowerUpInterval     = 5.0
+	bombPowerUpMax          = 10
+	bombTimerDuration       = 30.0
+	bombSlowDuration        = 1.0
+	bombSlowFactor          = 0.6
+	bombTimerBonus          = 10.0
+	bombBlastRadius         = 90.0
+	playersPerBomb          = 6
+	hotPotatoFuseStep       = 0.15
+	hotPotatoMaxFuseRate    = 3.0
+	fishPoints              = 1
+	goldenFishPoints        = 5
+	goldenFishChance        = 0.05
+	timeFishChance          = 0.1
+	timeFishSeconds         = 5.0
+	goldenChainDuration     = 8.0
+	goldenChainMultiplier   = 2
+	dataFileName            = "data.json"
+	mapsDirName             = "maps"
+	communityMapMaxBytes    = 64 << 10
+	communityMapNameLimit   = 40
+	communityMapWallLimit   = 64
+	communityMapSpawnLimit  = 32
+	reconnectGrace          = 10 * time.Second
+	matchmakingInterval     = time.Second
+	matchmakingWaitLimit    = 30 * time.Second
+	matchmakingFallbackWait = 90 * time.Second
+	ratingInitial           = 1500.0
+	ratingK                 = 32.0
+	maxTeams                = 4
+	captureWorldScale       = 3.0
+	captureRoundDuration    = 180.0
+	captureBaseRadius       = 60.0
+	captureCarrierSpeed     = 0.7
+	captureDropCooldown     = 1.0
+	captureFishReturn       = 10.0
+	captureScoreLimit       = 3
+	kingWorldScale          = 2.0
+	kingRoundDuration       = 120.0
+	hillRotateInterval      = 20.0
+	hillMaxCount            = 3
+	hillPointsPerSecond     = 1.0
+	hillKnockbackSpeed      = 420.0
+	hillKnockbackDecay      = 0.88
+	hideSeekProbeCooldown   = 3.0
+	hideSeekProbePenalty    = 5.0
+	hideSeekRedisguises     = 2
+	hideSeekTauntCooldown   = 10.0
+	hideSeekTauntPoints     = 1
+	hideSeekPingLifetime    = 2.0
+	hideSeekPingJitter      = 40.0
+	hidersPerSeeker         = 5
+	ghostSpeedMultiplier    = 1.2
+	ghostHauntRange         = 80.0
+	ghostHauntCooldown      = 8.0
+	ghostHauntDuration      = 2.0
+	ghostHauntSlow          = 0.5
+	ghostMarkDuration       = 5.0
+	ghostHauntImmunity      = 10.0
+	arenaSlideDuration      = 1.5
+	arenaCollapseWarning    = 3.0
+	arenaCollapseDuration   = 8.0
+	arenaCollapseCells      = 3
+	arenaMineWave           = 4
+	arenaMineMax            = 16
 )
 
 type vector struct {
@@ -75,22 +151,29 @@ type scoreEntry struct {
 }
 
 type playerState struct {
-	ID         string        `json:"id"`
-	Name       string        `json:"name"`
-	Ready      bool          `json:"ready"`
-	Alive      bool          `json:"alive"`
-	X          float64       `json:"x"`
-	Y          float64       `json:"y"`
-	Size       float64       `json:"size"`
-	Facing     int           `json:"facing"`
-	Moving     bool          `json:"moving"`
-	WalkCycle  float64       `json:"walkCycle"`
-	StepAccum  float64       `json:"stepAccumulator"`
-	Score      int           `json:"score"`
-	Health     int           `json:"health"`
-	Weapon     string        `json:"weapon,omitempty"`
-	Appearance catAppearance `json:"appearance"`
-	Disguise   string        `json:"disguise,omitempty"`
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Ready      bool           `json:"ready"`
+	Alive      bool           `json:"alive"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Size       float64        `json:"size"`
+	Facing     int            `json:"facing"`
+	Moving     bool           `json:"moving"`
+	WalkCycle  float64        `json:"walkCycle"`
+	StepAccum  float64        `json:"stepAccumulator"`
+	Score      int            `json:"score"`
+	Team       int            `json:"team"`
+	Health     int            `json:"health"`
+	Armor      int            `json:"armor"`
+	Weapon     string         `json:"weapon,omitempty"`
+	Ammo       int            `json:"ammo"`
+	Cooldown   float64        `json:"cooldown"`
+	Charges    int            `json:"charges"`
+	AbilityCD  float64        `json:"abilityCooldown"`
+	Appearance catAppearance  `json:"appearance"`
+	Disguise   string         `json:"disguise,omitempty"`
+	Effects    []statusEffect `json:"effects"`
 }
 
 type fishState struct {
@@ -104,29 +187,36 @@ type fishState struct {
 }
 
 type gameState struct {
-	RoomName   string         `json:"roomName"`
-	Mode       string         `json:"mode"`
-	Phase      string         `json:"phase"`
-	Countdown  float64        `json:"countdown"`
-	Remaining  float64        `json:"remaining"`
-	HidePhase  string         `json:"hidePhase,omitempty"`
-	ShootPhase string         `json:"shootPhase,omitempty"`
-	Message    string         `json:"message"`
-	SeekerID   string         `json:"seekerId"`
-	BombHolder string         `json:"bombHolder"`
-	BombTimer  float64        `json:"bombTimer"`
-	Players    []*playerState `json:"players"`
-	Fish       fishState      `json:"fish"`
-	Walls      []wall         `json:"walls"`
-	Mines      []mine         `json:"mines"`
-	PowerUp    powerUpState   `json:"powerUp"`
-	PowerUps   []powerUpState `json:"powerUps,omitempty"`
-	Shots      []shotEvent    `json:"shots,omitempty"`
-	Status     *statusEffect  `json:"statusEffect"`
-	WinnerID   string         `json:"winnerId"`
-	Golden     bool           `json:"goldenChainActive"`
-	TickIndex  uint32         `json:"tickIndex"`
-	ServerTime int64          `json:"serverTime"`
+	RoomName    string         `json:"roomName"`
+	Mode        string         `json:"mode"`
+	Phase       string         `json:"phase"`
+	Countdown   float64        `json:"countdown"`
+	Remaining   float64        `json:"remaining"`
+	HidePhase   string         `json:"hidePhase,omitempty"`
+	ShootPhase  string         `json:"shootPhase,omitempty"`
+	Message     string         `json:"message"`
+	SeekerIDs   []string       `json:"seekerIds"`
+	Bombs       []bomb         `json:"bombs"`
+	Players     []*playerState `json:"players"`
+	Fish        fishState      `json:"fish"`
+	Walls       []wall         `json:"walls"`
+	Mines       []mine         `json:"mines"`
+	PowerUp     powerUpState   `json:"powerUp"`
+	PowerUps    []powerUpState `json:"powerUps,omitempty"`
+	Shots       []shotEvent    `json:"shots,omitempty"`
+	Zone        *safeZone      `json:"zone,omitempty"`
+	Bases       []teamBase     `json:"bases,omitempty"`
+	FishCarrier string         `json:"fishCarrier,omitempty"`
+	Hills       []hillZone     `json:"hills,omitempty"`
+	Pings       []soundPing    `json:"pings,omitempty"`
+	Hazards     []hazardCell   `json:"hazards,omitempty"`
+	WinnerID    string         `json:"winnerId"`
+	WinnerTeam  int            `json:"winnerTeam"`
+	Golden      bool           `json:"goldenChainActive"`
+	HostID      string         `json:"hostId"`
+	Settings    roomSettings   `json:"settings"`
+	TickIndex   uint32         `json:"tickIndex"`
+	ServerTime  int64          `json:"serverTime"`
 }
 
 type wall struct {
@@ -160,10 +250,33 @@ type shotEvent struct {
 	Remaining float64 `json:"remaining"`
 }
 
+// statusEffectRule describes how an effect type scales with stacks. Speed
+// is the multiplier of the first stack, StackSpeed is added per extra
+// stack; effects without a Speed do not touch movement.
+type statusEffectRule struct {
+	Speed      float64
+	StackSpeed float64
+	MaxStacks  int
+	Duration   float64
+}
+
+var statusEffectRules = map[string]statusEffectRule{
+	"speedUp":      {Speed: 2, StackSpeed: 0.5, MaxStacks: 2, Duration: powerUpDuration},
+	"speedDown":    {Speed: 1 / 1.5, StackSpeed: -0.1, MaxStacks: 2, Duration: powerUpDuration},
+	"timeIncrease": {MaxStacks: 1, Duration: powerUpDuration},
+	"timeDecrease": {MaxStacks: 1, Duration: powerUpDuration},
+	"invert":       {MaxStacks: 1, Duration: invertDuration},
+	"shield":       {MaxStacks: 1, Duration: powerUpDuration},
+	"magnet":       {MaxStacks: 1, Duration: magnetDuration},
+	"ghost":        {MaxStacks: 1, Duration: ghostDuration},
+	"haunted":      {Speed: ghostHauntSlow, MaxStacks: 1, Duration: ghostHauntDuration},
+	"marked":       {MaxStacks: 1, Duration: ghostMarkDuration},
+}
+
 type statusEffect struct {
 	Type      string  `json:"type"`
 	Remaining float64 `json:"remaining"`
-	PlayerID  string  `json:"playerId,omitempty"`
+	Stacks    int     `json:"stacks"`
 }
 
 type gridCell struct {
@@ -172,43 +285,58 @@ type gridCell struct {
 }
 
 type wallSegment struct {
-	Row         int
-	Col         int
-	Length      int
-	Orientation string
+	Row         int    `json:"row"`
+	Col         int    `json:"col"`
+	Length      int    `json:"length"`
+	Orientation string `json:"orientation"`
 }
 
 type wsMessage struct {
-	Type       string        `json:"type"`
-	Ready      *bool         `json:"ready,omitempty"`
-	Vector     *vector       `json:"vector,omitempty"`
-	Shoot      *bool         `json:"shoot,omitempty"`
-	Message    *chatMessage  `json:"message,omitempty"`
-	Appearance catAppearance `json:"appearance,omitempty"`
-	State      *gameState    `json:"state,omitempty"`
-	Patch      *statePatch   `json:"patch,omitempty"`
-	Full       bool          `json:"full,omitempty"`
-	Error      string        `json:"error,omitempty"`
-	Binary     *bool         `json:"binary,omitempty"`
+	Type        string             `json:"type"`
+	Ready       *bool              `json:"ready,omitempty"`
+	Vector      *vector            `json:"vector,omitempty"`
+	Shoot       *bool              `json:"shoot,omitempty"`
+	Aim         *vector            `json:"aim,omitempty"`
+	Team        *int               `json:"team,omitempty"`
+	Stars       *int               `json:"stars,omitempty"`
+	Message     *chatMessage       `json:"message,omitempty"`
+	Appearance  catAppearance      `json:"appearance,omitempty"`
+	Settings    json.RawMessage    `json:"settings,omitempty"`
+	Room        string             `json:"room,omitempty"`
+	Mode        string             `json:"mode,omitempty"`
+	Queue       *queueStatus       `json:"queue,omitempty"`
+	Achievement *achievementUnlock `json:"achievement,omitempty"`
+	State       *gameState         `json:"state,omitempty"`
+	Patch       *statePatch        `json:"patch,omitempty"`
+	Full        bool               `json:"full,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Binary      *bool              `json:"binary,omitempty"`
 }
 
 type playerPatch struct {
-	ID         string        `json:"id"`
-	Name       *string       `json:"name,omitempty"`
-	Ready      *bool         `json:"ready,omitempty"`
-	Alive      *bool         `json:"alive,omitempty"`
-	X          *float64      `json:"x,omitempty"`
-	Y          *float64      `json:"y,omitempty"`
-	Size       *float64      `json:"size,omitempty"`
-	Facing     *int          `json:"facing,omitempty"`
-	Moving     *bool         `json:"moving,omitempty"`
-	WalkCycle  *float64      `json:"walkCycle,omitempty"`
-	StepAccum  *float64      `json:"stepAccumulator,omitempty"`
-	Score      *int          `json:"score,omitempty"`
-	Health     *int          `json:"health,omitempty"`
-	Weapon     *string       `json:"weapon,omitempty"`
-	Appearance catAppearance `json:"appearance,omitempty"`
-	Disguise   *string       `json:"disguise,omitempty"`
+	ID         string          `json:"id"`
+	Name       *string         `json:"name,omitempty"`
+	Ready      *bool           `json:"ready,omitempty"`
+	Alive      *bool           `json:"alive,omitempty"`
+	X          *float64        `json:"x,omitempty"`
+	Y          *float64        `json:"y,omitempty"`
+	Size       *float64        `json:"size,omitempty"`
+	Facing     *int            `json:"facing,omitempty"`
+	Moving     *bool           `json:"moving,omitempty"`
+	WalkCycle  *float64        `json:"walkCycle,omitempty"`
+	StepAccum  *float64        `json:"stepAccumulator,omitempty"`
+	Score      *int            `json:"score,omitempty"`
+	Team       *int            `json:"team,omitempty"`
+	Health     *int            `json:"health,omitempty"`
+	Armor      *int            `json:"armor,omitempty"`
+	Weapon     *string         `json:"weapon,omitempty"`
+	Ammo       *int            `json:"ammo,omitempty"`
+	Cooldown   *float64        `json:"cooldown,omitempty"`
+	Charges    *int            `json:"charges,omitempty"`
+	AbilityCD  *float64        `json:"abilityCooldown,omitempty"`
+	Appearance catAppearance   `json:"appearance,omitempty"`
+	Disguise   *string         `json:"disguise,omitempty"`
+	Effects    *[]statusEffect `json:"effects,omitempty"`
 }
 
 type statePatch struct {
@@ -219,13 +347,20 @@ type statePatch struct {
 	HidePhase      *string        `json:"hidePhase,omitempty"`
 	ShootPhase     *string        `json:"shootPhase,omitempty"`
 	Message        *string        `json:"message,omitempty"`
-	SeekerID       *string        `json:"seekerId,omitempty"`
-	BombHolder     *string        `json:"bombHolder,omitempty"`
-	BombTimer      *float64       `json:"bombTimer,omitempty"`
+	SeekerIDs      []string       `json:"seekerIds"`
+	Bombs          []bomb         `json:"bombs"`
 	WinnerID       *string        `json:"winnerId,omitempty"`
+	WinnerTeam     *int           `json:"winnerTeam,omitempty"`
 	Golden         *bool          `json:"goldenChainActive,omitempty"`
+	HostID         *string        `json:"hostId,omitempty"`
+	Settings       *roomSettings  `json:"settings,omitempty"`
 	Shots          []shotEvent    `json:"shots,omitempty"`
-	Status         *statusEffect  `json:"statusEffect,omitempty"`
+	Zone           *safeZone      `json:"zone,omitempty"`
+	Bases          []teamBase     `json:"bases"`
+	FishCarrier    *string        `json:"fishCarrier,omitempty"`
+	Hills          []hillZone     `json:"hills"`
+	Pings          []soundPing    `json:"pings"`
+	Hazards        []hazardCell   `json:"hazards"`
 	Fish           *fishState     `json:"fish,omitempty"`
 	PowerUp        *powerUpState  `json:"powerUp,omitempty"`
 	PowerUps       []powerUpState `json:"powerUps,omitempty"`
@@ -44,16 +44,104 @@ func quantizePlayerForSend(p *playerState) {
 	p.Size = quantizeCoord(p.Size)
 	p.WalkCycle = quantizeWalkCycle(p.WalkCycle)
 	p.StepAccum = roundFloat(p.StepAccum, 3)
+	p.Cooldown = quantizeSeconds(p.Cooldown)
+	for i := range p.Effects {
+		p.Effects[i].Remaining = quantizeSeconds(p.Effects[i].Remaining)
+	}
+}
+
+func effectsEqual(a, b []statusEffect) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Stacks != b[i].Stacks || floatChanged(a[i].Remaining, b[i].Remaining) {
+			return false
+		}
+	}
+	return true
 }
 
-func statusEqual(a, b *statusEffect) bool {
+func zoneEqual(a, b *safeZone) bool {
 	if a == nil && b == nil {
 		return true
 	}
 	if a == nil || b == nil {
 		return false
 	}
-	return a.Type == b.Type && a.PlayerID == b.PlayerID && !floatChanged(a.Remaining, b.Remaining)
+	return !floatChanged(a.X, b.X) && !floatChanged(a.Y, b.Y) && !floatChanged(a.Radius, b.Radius) && !floatChanged(a.TargetRadius, b.TargetRadius)
+}
+
+func basesEqual(a, b []teamBase) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Team != b[i].Team || floatChanged(a[i].X, b[i].X) || floatChanged(a[i].Y, b[i].Y) || floatChanged(a[i].Radius, b[i].Radius) {
+			return false
+		}
+	}
+	return true
+}
+
+func hillsEqual(a, b []hillZone) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pingsEqual(a, b []soundPing) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hazardsEqual(a, b []hazardCell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func bombsEqual(a, b []bomb) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Holder != b[i].Holder || a[i].Passes != b[i].Passes || floatChanged(a[i].Timer, b[i].Timer) {
+			return false
+		}
+	}
+	return true
 }
 
 func fishEqual(a, b fishState) bool {
@@ -213,6 +301,13 @@ func clampFloat(v, min, max float64) float64 {
 	return v
 }
 
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 func clampInt(v, min, max int) int {
 	if v < min {
 		return min
@@ -233,6 +328,31 @@ func fallbackName(name string) string {
 	return name
 }
 
+func pluralRu(n int, one, few, many string) string {
+	mod100 := n % 100
+	mod10 := n % 10
+	if mod100 >= 11 && mod100 <= 14 {
+		return many
+	}
+	switch mod10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	default:
+		return many
+	}
+}
+
+func formatSecondsRu(seconds float64) string {
+	total := int(math.Round(seconds))
+	if total >= 60 && total%60 == 0 {
+		minutes := total / 60
+		return fmt.Sprintf("%d %s", minutes, pluralRu(minutes, "минута", "минуты", "минут"))
+	}
+	return fmt.Sprintf("%d %s", total, pluralRu(total, "секунда", "секунды", "секунд"))
+}
+
 func writeJSON(w http.ResponseWriter, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(payload)
@@ -241,7 +361,7 @@ func writeJSON(w http.ResponseWriter, payload any) {
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == http.MethodOptions {
@@ -252,3 +372,12 @@ func withCORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func sortedPlayerIDs(players map[string]*playerState) []string {
+	ids := make([]string, 0, len(players))
+	for id := range players {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
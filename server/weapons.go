@@ -0,0 +1,47 @@
+package main
+
+import "math"
+
+type weaponStats struct {
+	DamageScale  float64
+	Range        float64
+	Cooldown     float64
+	Spread       float64
+	Ammo         int
+	Pierce       bool
+	SplashRadius float64
+	SplashScale  float64
+}
+
+// weaponTable scales damage by the room's shooterDamage setting so that the
+// host-configured value still controls how many hits a kill takes.
+var weaponTable = map[string]weaponStats{
+	"blaster": {DamageScale: 1, Range: shooterShotRange, Cooldown: 0.5, Spread: 0.04, Ammo: 20},
+	"laser":   {DamageScale: 0.8, Range: 360, Cooldown: 0.9, Ammo: 10, Pierce: true},
+	"pistol":  {DamageScale: 0.5, Range: 180, Cooldown: 0.2, Spread: 0.1, Ammo: 40},
+	"plasma":  {DamageScale: 1.2, Range: 200, Cooldown: 1.2, Spread: 0.06, Ammo: 6, SplashRadius: 60, SplashScale: 0.5},
+}
+
+func lookupWeapon(name string) weaponStats {
+	if weapon, ok := weaponTable[name]; ok {
+		return weapon
+	}
+	return weaponTable["blaster"]
+}
+
+func (w weaponStats) damage(base int) int {
+	return int(math.Max(1, math.Round(float64(base)*w.DamageScale)))
+}
+
+func (w weaponStats) splashDamage(base int) int {
+	return int(math.Max(1, math.Round(float64(base)*w.DamageScale*w.SplashScale)))
+}
+
+func containsPlayer(players []*playerState, target *playerState) bool {
+	for _, p := range players {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}